@@ -0,0 +1,270 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nowsecure/nowsecure-ci/internal"
+	"github.com/nowsecure/nowsecure-ci/internal/appconfig"
+	"github.com/nowsecure/nowsecure-ci/internal/output"
+)
+
+//revive:disable:exported
+func AppCommand(ctx context.Context, v *viper.Viper, config *internal.BaseConfig) *cobra.Command {
+	appCmd := &cobra.Command{
+		Use:   "app",
+		Short: "Manage applications on the NowSecure Platform",
+	}
+
+	appCmd.AddCommand(
+		ConfigCommand(ctx, config),
+	)
+
+	return appCmd
+}
+
+func ConfigCommand(ctx context.Context, config *internal.BaseConfig) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the dynamic, static and integrations configuration of an application from a YAML file",
+	}
+
+	configCmd.AddCommand(
+		GetCommand(ctx, config),
+		SetCommand(ctx, config),
+		DiffCommand(ctx, config),
+	)
+
+	return configCmd
+}
+
+// DriftError fails ns app config diff --exit-code when the platform's configuration differs from the file
+type DriftError struct {
+	Changes int
+}
+
+func (e DriftError) Error() string {
+	return fmt.Sprintf("app configuration differs from the file in %d settings", e.Changes)
+}
+
+func (e DriftError) ExitCode() int {
+	return 1
+}
+
+func GetCommand(c context.Context, config *internal.BaseConfig) *cobra.Command {
+	getCmd := &cobra.Command{
+		Use:   "get [config-file]",
+		Short: "Write the configuration of an application as YAML to a file, or stdout",
+		Example: `# Start version-controlling the configuration of an app
+ns app config get ./nowsecure/app-config.yaml \
+  --platform android \
+  --package com.example.app \
+  --group-ref YOUR_GROUP_UUID
+`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ref, err := appFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			ctx := internal.LoggerWithLevel(config.LogLevel, config.Redactor).
+				WithContext(cmd.Context())
+
+			if len(args) == 0 {
+				return Get(ctx, ref, cmd.OutOrStdout(), config)
+			}
+
+			file, err := os.OpenFile(args[0], os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			if err := Get(ctx, ref, file, config); err != nil {
+				return err
+			}
+			zerolog.Ctx(ctx).Info().Str("Path", args[0]).Msg("App configuration saved")
+			return nil
+		},
+	}
+
+	addAppFlags(c, getCmd)
+
+	return getCmd
+}
+
+func SetCommand(c context.Context, config *internal.BaseConfig) *cobra.Command {
+	setCmd := &cobra.Command{
+		Use:   "set [config-file]",
+		Short: "Update the configuration of an application with the settings declared in a YAML file",
+		Example: `# Apply the version-controlled configuration, reading the login password from the environment
+APP_LOGIN_PASSWORD=... ns app config set ./nowsecure/app-config.yaml \
+  --platform android \
+  --package com.example.app \
+  --group-ref YOUR_GROUP_UUID
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ref, err := appFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			ctx := internal.LoggerWithLevel(config.LogLevel, config.Redactor).
+				WithContext(cmd.Context())
+
+			return Set(ctx, ref, args[0], config)
+		},
+	}
+
+	addAppFlags(c, setCmd)
+
+	return setCmd
+}
+
+func DiffCommand(c context.Context, config *internal.BaseConfig) *cobra.Command {
+	diffCmd := &cobra.Command{
+		Use:   "diff [config-file]",
+		Short: "List the settings declared in a YAML file that differ from the configuration of an application",
+		Example: `# Fail a pipeline when the configuration was changed outside of version control
+ns app config diff ./nowsecure/app-config.yaml \
+  --platform android \
+  --package com.example.app \
+  --group-ref YOUR_GROUP_UUID \
+  --exit-code
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ref, err := appFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			exitCode, err := cmd.Flags().GetBool("exit-code")
+			if err != nil {
+				return err
+			}
+			ctx := internal.LoggerWithLevel(config.LogLevel, config.Redactor).
+				WithContext(cmd.Context())
+
+			return Diff(ctx, ref, args[0], exitCode, config)
+		},
+	}
+
+	addAppFlags(c, diffCmd)
+	diffCmd.Flags().Bool("exit-code", false, "exit with code 1 when the configuration differs from the file")
+
+	return diffCmd
+}
+
+func addAppFlags(c context.Context, cmd *cobra.Command) {
+	cmd.Flags().String("platform", "", "platform of the app. One of: android, ios")
+	cmd.Flags().String("package", "", "package name of the app")
+
+	requiredErrors := []error{
+		cmd.MarkFlagRequired("platform"),
+		cmd.MarkFlagRequired("package"),
+	}
+	if errs := errors.Join(requiredErrors...); errs != nil {
+		zerolog.Ctx(c).Panic().Err(errs).Msg("Failed marking app flags")
+	}
+}
+
+func appFromFlags(cmd *cobra.Command) (appconfig.AppRef, error) {
+	platform, err := cmd.Flags().GetString("platform")
+	if err != nil {
+		return appconfig.AppRef{}, err
+	}
+
+	platform = strings.ToLower(platform)
+	if platform != "android" && platform != "ios" {
+		return appconfig.AppRef{}, fmt.Errorf("invalid platform %q, must be one of: android, ios", platform)
+	}
+
+	packageName, err := cmd.Flags().GetString("package")
+	if err != nil {
+		return appconfig.AppRef{}, err
+	}
+
+	return appconfig.AppRef{Platform: platform, Package: packageName}, nil
+}
+
+// Get writes the configuration of an application as YAML. Secrets are written as [REDACTED] when sensitive values
+// are hidden, which ns app config set leaves unchanged on the platform
+func Get(ctx context.Context, ref appconfig.AppRef, w io.Writer, config *internal.BaseConfig) error {
+	current, err := appconfig.Fetch(ctx, config.PlatformClient, ref, config.Group)
+	if err != nil {
+		return err
+	}
+
+	redacted, err := config.Redactor.Value(map[string]any(current))
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(redacted)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// Set pushes the settings declared in a configuration file that differ from the platform
+func Set(ctx context.Context, ref appconfig.AppRef, path string, config *internal.BaseConfig) error {
+	result, err := syncConfig(ctx, ref, path, false, config)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(result, config)
+}
+
+// Diff lists the settings declared in a configuration file that differ from the platform, failing with a
+// DriftError when exitCode is set and there are any
+func Diff(ctx context.Context, ref appconfig.AppRef, path string, exitCode bool, config *internal.BaseConfig) error {
+	result, err := syncConfig(ctx, ref, path, true, config)
+	if err != nil {
+		return err
+	}
+
+	if err := writeOutput(result, config); err != nil {
+		return err
+	}
+
+	if exitCode && len(result.Changes) > 0 {
+		return DriftError{Changes: len(result.Changes)}
+	}
+
+	return nil
+}
+
+// syncConfig runs appconfig.Sync for an application of the configured group
+func syncConfig(ctx context.Context, ref appconfig.AppRef, path string, dryRun bool, config *internal.BaseConfig) (*appconfig.ConfigOutput, error) {
+	return appconfig.Sync(ctx, config.PlatformClient, appconfig.SyncParams{
+		App:           ref,
+		Group:         config.Group,
+		Path:          path,
+		DryRun:        dryRun,
+		HideSensitive: config.HideSensitive,
+	})
+}
+
+func writeOutput(result *appconfig.ConfigOutput, config *internal.BaseConfig) error {
+	w, err := output.New(config.Output, config.OutputFormat, config.OutputOptions())
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	return w.Write(result)
+}
@@ -0,0 +1,269 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nowsecure/nowsecure-ci/internal"
+	"github.com/nowsecure/nowsecure-ci/internal/appconfig"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+	"github.com/nowsecure/nowsecure-ci/internal/redact"
+)
+
+var ref = appconfig.AppRef{Platform: "android", Package: "com.example.app"}
+
+func getTestConfig(t *testing.T, doer *platformapi.TestRequestDoer) *internal.BaseConfig {
+	client, err := platformapi.ClientFromConfig(platformapi.Config{
+		Host:      "https://localhost:8080",
+		UserAgent: "test/1.0",
+		Token:     "token",
+	}, doer)
+	require.NoError(t, err)
+
+	return &internal.BaseConfig{
+		PlatformClient: client,
+		LogLevel:       zerolog.DebugLevel,
+		Output:         filepath.Join(t.TempDir(), "output.json"),
+	}
+}
+
+func platformConfig() map[string]any {
+	return map[string]any{
+		"dynamic": map[string]any{
+			"login": map[string]any{
+				"username": "ci-user@example.com",
+				"password": "old-password",
+			},
+			"timeout": 300,
+		},
+		"static": map[string]any{
+			"exclude_libraries": false,
+		},
+	}
+}
+
+func useAppConfig(t *testing.T, doer *platformapi.TestRequestDoer, config map[string]any) {
+	body, err := json.Marshal(config)
+	require.NoError(t, err)
+
+	doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/app/android/com.example.app/config")
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}, nil)
+}
+
+// useSetAppConfig records the body of the configuration update
+func useSetAppConfig(doer *platformapi.TestRequestDoer, posted *map[string]any) {
+	doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/app/android/com.example.app/config") {
+			return false
+		}
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return false
+		}
+		return json.Unmarshal(body, posted) == nil
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader("{}")),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}, nil)
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "app-config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func readOutput(t *testing.T, config *internal.BaseConfig) appconfig.ConfigOutput {
+	data, err := os.ReadFile(config.Output)
+	require.NoError(t, err)
+
+	var result appconfig.ConfigOutput
+	require.NoError(t, json.Unmarshal(data, &result))
+	return result
+}
+
+func TestGet(t *testing.T) {
+	t.Run("Configuration is written as YAML", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := getTestConfig(t, doer)
+		useAppConfig(t, doer, platformConfig())
+
+		var buf bytes.Buffer
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, Get(ctx, ref, &buf, config))
+
+		var written map[string]any
+		require.NoError(t, yaml.Unmarshal(buf.Bytes(), &written))
+		assert.Equal(t, "old-password", written["dynamic"].(map[string]any)["login"].(map[string]any)["password"])
+		assert.Equal(t, false, written["static"].(map[string]any)["exclude_libraries"])
+	})
+
+	t.Run("Secrets are written redacted when sensitive values are hidden", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := getTestConfig(t, doer)
+		config.HideSensitive = true
+		var err error
+		config.Redactor, err = redact.New(true, nil, nil)
+		require.NoError(t, err)
+		useAppConfig(t, doer, platformConfig())
+
+		var buf bytes.Buffer
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, Get(ctx, ref, &buf, config))
+		assert.NotContains(t, buf.String(), "old-password")
+		assert.Contains(t, buf.String(), "password: '[REDACTED]'")
+		assert.Contains(t, buf.String(), "ci-user@example.com")
+	})
+}
+
+func TestDiff(t *testing.T) {
+	t.Run("Declared settings that differ are listed", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := getTestConfig(t, doer)
+		useAppConfig(t, doer, platformConfig())
+		t.Setenv("APP_LOGIN_PASSWORD", "new-password")
+
+		path := writeConfigFile(t, `
+dynamic:
+  login:
+    username: ci-user@example.com
+    password: ${APP_LOGIN_PASSWORD}
+  timeout: 300
+static:
+  exclude_libraries: true
+  rules: [a, b]
+`)
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, Diff(ctx, ref, path, false, config))
+
+		result := readOutput(t, config)
+		assert.Equal(t, ref, result.AppRef)
+		assert.False(t, result.Applied)
+		assert.Equal(t, []appconfig.Change{
+			{Path: "dynamic.login.password", Action: appconfig.ActionChange, Old: "old-password", New: "new-password"},
+			{Path: "static.exclude_libraries", Action: appconfig.ActionChange, Old: false, New: true},
+			{Path: "static.rules", Action: appconfig.ActionAdd, New: []any{"a", "b"}},
+		}, result.Changes)
+	})
+
+	t.Run("Secrets are masked and exit code set on drift", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := getTestConfig(t, doer)
+		config.HideSensitive = true
+		useAppConfig(t, doer, platformConfig())
+
+		path := writeConfigFile(t, `
+dynamic:
+  login:
+    password: new-password
+`)
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err := Diff(ctx, ref, path, true, config)
+		var drift DriftError
+		require.ErrorAs(t, err, &drift)
+		assert.Equal(t, 1, drift.Changes)
+		assert.Equal(t, 1, drift.ExitCode())
+
+		result := readOutput(t, config)
+		require.Len(t, result.Changes, 1)
+		assert.Equal(t, redact.Mask, result.Changes[0].Old)
+		assert.Equal(t, redact.Mask, result.Changes[0].New)
+	})
+
+	t.Run("Unset environment variables and unknown sections are rejected", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := getTestConfig(t, doer)
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+
+		path := writeConfigFile(t, "dynamic:\n  login:\n    password: ${NS_TEST_UNSET_PASSWORD}\n")
+		require.ErrorContains(t, Diff(ctx, ref, path, false, config), "dynamic.login.password references unset environment variable NS_TEST_UNSET_PASSWORD")
+
+		path = writeConfigFile(t, "runtime:\n  enabled: true\n")
+		require.ErrorContains(t, Diff(ctx, ref, path, false, config), `unknown section "runtime"`)
+		doer.AssertNotCalled(t, "Do", mock.Anything)
+	})
+}
+
+func TestSet(t *testing.T) {
+	t.Run("Changes are pushed with the settings the file doesn't declare", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := getTestConfig(t, doer)
+		useAppConfig(t, doer, platformConfig())
+		var posted map[string]any
+		useSetAppConfig(doer, &posted)
+
+		path := writeConfigFile(t, `
+dynamic:
+  login:
+    username: other-user@example.com
+    password: '[REDACTED]'
+`)
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, Set(ctx, ref, path, config))
+
+		assert.Equal(t, map[string]any{
+			"dynamic": map[string]any{
+				"login": map[string]any{
+					"username": "other-user@example.com",
+					"password": "old-password",
+				},
+				"timeout": float64(300),
+			},
+		}, posted)
+
+		result := readOutput(t, config)
+		assert.True(t, result.Applied)
+		require.Len(t, result.Changes, 1)
+		assert.Equal(t, "dynamic.login.username", result.Changes[0].Path)
+	})
+
+	t.Run("Configuration in sync is not pushed", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := getTestConfig(t, doer)
+		useAppConfig(t, doer, platformConfig())
+
+		path := writeConfigFile(t, "dynamic:\n  timeout: 300\nstatic:\n  exclude_libraries: false\n")
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, Set(ctx, ref, path, config))
+
+		result := readOutput(t, config)
+		assert.False(t, result.Applied)
+		assert.Empty(t, result.Changes)
+		doer.AssertNumberOfCalls(t, "Do", 1)
+	})
+
+	t.Run("Redacted value without one on the platform is rejected", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := getTestConfig(t, doer)
+		useAppConfig(t, doer, platformConfig())
+
+		path := writeConfigFile(t, "dynamic:\n  api_token: '[REDACTED]'\n")
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.ErrorContains(t, Set(ctx, ref, path, config), "dynamic.api_token is [REDACTED] but has no value on the platform to keep")
+		doer.AssertNumberOfCalls(t, "Do", 1)
+	})
+}
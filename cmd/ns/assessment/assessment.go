@@ -0,0 +1,76 @@
+package assessment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/nowsecure/nowsecure-ci/internal"
+)
+
+//revive:disable:exported
+func AssessmentCommand(ctx context.Context, v *viper.Viper, config *internal.BaseConfig) *cobra.Command {
+	assessmentCmd := &cobra.Command{
+		Use:   "assessment",
+		Short: "Check on an assessment that has already been triggered",
+	}
+
+	assessmentCmd.AddCommand(
+		StatusCommand(ctx, v, config),
+		WaitCommand(ctx, v, config),
+		CancelCommand(ctx, config),
+	)
+
+	return assessmentCmd
+}
+
+// TaskRef identifies an assessment on the platform
+type TaskRef struct {
+	Platform string  `json:"platform"`
+	Package  string  `json:"package"`
+	Task     float64 `json:"task"`
+}
+
+func addTaskFlags(c context.Context, cmd *cobra.Command) {
+	cmd.Flags().String("platform", "", "platform of the app. One of: android, ios")
+	cmd.Flags().String("package", "", "package name of the app")
+	cmd.Flags().Int("task", 0, "task ID of the assessment")
+
+	requiredErrors := []error{
+		cmd.MarkFlagRequired("platform"),
+		cmd.MarkFlagRequired("package"),
+		cmd.MarkFlagRequired("task"),
+	}
+	if errs := errors.Join(requiredErrors...); errs != nil {
+		zerolog.Ctx(c).Panic().Err(errs).Msg("Failed marking assessment flags")
+	}
+}
+
+func taskFromFlags(cmd *cobra.Command) (TaskRef, error) {
+	platform, err := cmd.Flags().GetString("platform")
+	if err != nil {
+		return TaskRef{}, err
+	}
+
+	platform = strings.ToLower(platform)
+	if platform != "android" && platform != "ios" {
+		return TaskRef{}, fmt.Errorf("invalid platform %q, must be one of: android, ios", platform)
+	}
+
+	packageName, err := cmd.Flags().GetString("package")
+	if err != nil {
+		return TaskRef{}, err
+	}
+
+	task, err := cmd.Flags().GetInt("task")
+	if err != nil {
+		return TaskRef{}, err
+	}
+
+	return TaskRef{Platform: platform, Package: packageName, Task: float64(task)}, nil
+}
@@ -0,0 +1,65 @@
+package assessment
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/nowsecure/nowsecure-ci/internal"
+	"github.com/nowsecure/nowsecure-ci/internal/output"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+func CancelCommand(c context.Context, config *internal.BaseConfig) *cobra.Command {
+	cancelCmd := &cobra.Command{
+		Use:   "cancel",
+		Short: "Cancel a running assessment",
+		Example: `# Stop an assessment that is no longer needed
+ns assessment cancel \
+  --platform android \
+  --package com.example.app \
+  --task 12345
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ref, err := taskFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			ctx := internal.LoggerWithLevel(config.LogLevel, config.Redactor).
+				WithContext(cmd.Context())
+
+			return Cancel(ctx, ref, config)
+		},
+	}
+
+	addTaskFlags(c, cancelCmd)
+
+	return cancelCmd
+}
+
+type CancelOutput struct {
+	TaskRef
+	Status string `json:"status"`
+}
+
+func Cancel(ctx context.Context, ref TaskRef, config *internal.BaseConfig) error {
+	w, err := output.New(config.Output, config.OutputFormat, config.OutputOptions())
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	status, err := platformapi.CancelAssessment(ctx, config.PlatformClient, platformapi.CancelAssessmentParams{
+		Platform:    ref.Platform,
+		PackageName: ref.Package,
+		TaskId:      ref.Task,
+	})
+	if err != nil {
+		return err
+	}
+
+	zerolog.Ctx(ctx).Info().Str("Status", status).Msg("Cancelled assessment")
+	return w.Write(CancelOutput{TaskRef: ref, Status: status})
+}
@@ -0,0 +1,144 @@
+package assessment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/nowsecure/nowsecure-ci/cmd/ns/run"
+	"github.com/nowsecure/nowsecure-ci/internal"
+	"github.com/nowsecure/nowsecure-ci/internal/output"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+func StatusCommand(c context.Context, v *viper.Viper, config *internal.BaseConfig) *cobra.Command {
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Report the status of an existing assessment, optionally waiting for it to complete",
+		Example: `# Report the current status of an assessment
+ns assessment status \
+  --platform android \
+  --package com.example.app \
+  --task 12345 \
+  --group-ref YOUR_GROUP_UUID
+
+# Wait for an assessment triggered with --poll-for-minutes 0 and apply a score threshold
+ns assessment status \
+  --platform android \
+  --package com.example.app \
+  --task 12345 \
+  --group-ref YOUR_GROUP_UUID \
+  --wait \
+  --poll-for-minutes 60 \
+  --minimum-score 70
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := run.BindResultFlags(v, cmd.Flags()); err != nil {
+				return err
+			}
+
+			ref, err := taskFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			wait, err := cmd.Flags().GetBool("wait")
+			if err != nil {
+				return err
+			}
+
+			config, err := internal.NewRunConfig(v)
+			if err != nil {
+				return err
+			}
+			config.Platform = ref.Platform
+			ctx := internal.LoggerWithLevel(config.LogLevel, config.Redactor).
+				WithContext(cmd.Context())
+
+			if wait {
+				return Wait(ctx, ref, config)
+			}
+			return Status(ctx, ref, config)
+		},
+	}
+
+	addTaskFlags(c, statusCmd)
+	statusCmd.Flags().Bool("wait", false, "poll until the assessment completes and apply the same gates as ns run")
+	run.AddResultFlags(c, statusCmd.Flags(), config)
+
+	return statusCmd
+}
+
+type StatusOutput struct {
+	TaskRef
+	Status        string   `json:"status"`
+	AdjustedScore *float32 `json:"adjusted_score,omitempty"`
+	TaskErrorCode *string  `json:"task_error_code,omitempty"`
+}
+
+func Status(ctx context.Context, ref TaskRef, config *internal.RunConfig) error {
+	w, err := output.New(config.Output, config.OutputFormat, config.OutputOptions())
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	resp, err := platformapi.GetAssessment(ctx, config.PlatformClient, platformapi.GetAssessmentParams{
+		Platform:    ref.Platform,
+		PackageName: ref.Package,
+		TaskId:      ref.Task,
+		Group:       config.Group,
+	})
+	if err != nil {
+		return err
+	}
+
+	status := platformapi.AssessmentStatus(resp)
+	result := StatusOutput{
+		TaskRef: ref,
+		Status:  status.String(),
+	}
+	if resp.JSON2XX != nil {
+		result.AdjustedScore = resp.JSON2XX.AdjustedScore
+		result.TaskErrorCode = resp.JSON2XX.TaskErrorCode
+	}
+
+	zerolog.Ctx(ctx).Info().Str("Status", result.Status).Msg("Assessment status")
+	if err := w.Write(result); err != nil {
+		return err
+	}
+
+	if status == platformapi.Failed {
+		errorCode := "nil"
+		if result.TaskErrorCode != nil {
+			errorCode = *result.TaskErrorCode
+		}
+		return fmt.Errorf("assessment failed with %v error code", errorCode)
+	}
+
+	return nil
+}
+
+// Wait polls an existing assessment until it completes and then reports on it like ns run
+func Wait(ctx context.Context, ref TaskRef, config *internal.RunConfig) error {
+	if config.PollForMinutes <= 0 {
+		return fmt.Errorf("cannot wait for an assessment without setting a nonzero poll-for-minutes")
+	}
+
+	w, err := output.New(config.Output, config.OutputFormat, config.OutputOptions())
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	taskResponse, err := run.AwaitAssessment(ctx, config, ref.Platform, ref.Package, ref.Task)
+	if err != nil {
+		return err
+	}
+
+	return run.ReportResults(ctx, config, w, ref.Task, taskResponse)
+}
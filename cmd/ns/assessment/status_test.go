@@ -0,0 +1,242 @@
+package assessment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nowsecure/nowsecure-ci/internal"
+	"github.com/nowsecure/nowsecure-ci/internal/output"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+	"github.com/nowsecure/nowsecure-ci/internal/state"
+)
+
+func getTestConfig(t *testing.T, doer *platformapi.TestRequestDoer) *internal.RunConfig {
+	client, err := platformapi.ClientFromConfig(platformapi.Config{
+		Host:      "https://localhost:8080",
+		UserAgent: "test/1.0",
+		Token:     "token",
+	}, doer)
+	require.NoError(t, err)
+
+	return &internal.RunConfig{
+		BaseConfig: internal.BaseConfig{
+			APIHost:        "https://localhost:8080",
+			UIHost:         "https://localhost:8081",
+			PlatformClient: client,
+			LogLevel:       zerolog.DebugLevel,
+			OutputFormat:   output.JSON,
+		},
+		PollingInterval: time.Second,
+	}
+}
+
+func useAssessment(t *testing.T, doer *platformapi.TestRequestDoer, statusCode int, assessment map[string]any) {
+	body, err := json.Marshal(assessment)
+	require.NoError(t, err)
+
+	bodyReader := bytes.NewReader(body)
+	doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/app/android/com.example.app/assessment/12345")
+	})).Run(func(args mock.Arguments) {
+		_, err := bodyReader.Seek(0, 0)
+		require.NoError(t, err)
+	}).Return(&http.Response{
+		StatusCode: statusCode,
+		Body:       io.NopCloser(bodyReader),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}, nil)
+}
+
+func readStatus(t *testing.T, path string) StatusOutput {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var status StatusOutput
+	require.NoError(t, json.Unmarshal(data, &status))
+	return status
+}
+
+func TestStatus(t *testing.T) {
+	ref := TaskRef{Platform: "android", Package: "com.example.app", Task: 12345}
+
+	t.Run("Completed assessment reports its score", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := getTestConfig(t, doer)
+		config.Output = filepath.Join(t.TempDir(), "status.json")
+
+		useAssessment(t, doer, http.StatusOK, map[string]any{
+			"package":        "com.example.app",
+			"platform":       "android",
+			"task":           12345,
+			"task_status":    "completed",
+			"adjusted_score": 82.5,
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, Status(ctx, ref, config))
+
+		status := readStatus(t, config.Output)
+		assert.Equal(t, "completed", status.Status)
+		assert.Equal(t, ref, status.TaskRef)
+		require.NotNil(t, status.AdjustedScore)
+		assert.InDelta(t, 82.5, *status.AdjustedScore, 0.001)
+	})
+
+	t.Run("Completed assessment without a score is pending", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := getTestConfig(t, doer)
+		config.Output = filepath.Join(t.TempDir(), "status.json")
+
+		useAssessment(t, doer, http.StatusOK, map[string]any{
+			"package":     "com.example.app",
+			"platform":    "android",
+			"task":        12345,
+			"task_status": "completed",
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, Status(ctx, ref, config))
+		assert.Equal(t, "pending", readStatus(t, config.Output).Status)
+	})
+
+	t.Run("Failed assessment returns an error", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := getTestConfig(t, doer)
+		config.Output = filepath.Join(t.TempDir(), "status.json")
+
+		useAssessment(t, doer, http.StatusOK, map[string]any{
+			"package":         "com.example.app",
+			"platform":        "android",
+			"task":            12345,
+			"task_status":     "failed",
+			"task_error_code": "install_failed",
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.ErrorContains(t, Status(ctx, ref, config), "install_failed")
+		assert.Equal(t, "failed", readStatus(t, config.Output).Status)
+	})
+}
+
+func TestWait(t *testing.T) {
+	ref := TaskRef{Platform: "android", Package: "com.example.app", Task: 12345}
+
+	t.Run("Completed assessment passes the score gate", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := getTestConfig(t, doer)
+		config.PollForMinutes = 1
+		config.MinimumScore = 50
+
+		useAssessment(t, doer, http.StatusOK, map[string]any{
+			"package":        "com.example.app",
+			"platform":       "android",
+			"task":           12345,
+			"task_status":    "completed",
+			"adjusted_score": 82.5,
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, Wait(ctx, ref, config))
+	})
+
+	t.Run("Completed assessment below the minimum score fails", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := getTestConfig(t, doer)
+		config.PollForMinutes = 1
+		config.MinimumScore = 90
+
+		useAssessment(t, doer, http.StatusOK, map[string]any{
+			"package":        "com.example.app",
+			"platform":       "android",
+			"task":           12345,
+			"task_status":    "completed",
+			"adjusted_score": 82.5,
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.ErrorContains(t, Wait(ctx, ref, config), "less than the required minimum")
+	})
+
+	t.Run("Resumes the assessment recorded in a state file", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := getTestConfig(t, doer)
+		config.PollForMinutes = 1
+		config.MinimumScore = 50
+
+		path := filepath.Join(t.TempDir(), state.DefaultFile)
+		require.NoError(t, state.New("android", "com.example.app", 12345, config.Group, "").Save(path))
+		s, err := state.Load(path)
+		require.NoError(t, err)
+
+		useAssessment(t, doer, http.StatusOK, map[string]any{
+			"package":        "com.example.app",
+			"platform":       "android",
+			"task":           12345,
+			"task_status":    "completed",
+			"adjusted_score": 82.5,
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, Wait(ctx, TaskRef{Platform: s.Platform, Package: s.Package, Task: s.Task}, config))
+	})
+
+	t.Run("Waiting requires a poll duration", func(t *testing.T) {
+		config := getTestConfig(t, &platformapi.TestRequestDoer{})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.ErrorContains(t, Wait(ctx, ref, config), "nonzero poll-for-minutes")
+	})
+}
+
+func TestCancel(t *testing.T) {
+	doer := &platformapi.TestRequestDoer{}
+	config := getTestConfig(t, doer)
+	config.Output = filepath.Join(t.TempDir(), "cancel.json")
+
+	doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/app/android/com.example.app/assessment/12345/cancel")
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"status":"cancelled"}`)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}, nil)
+
+	ref := TaskRef{Platform: "android", Package: "com.example.app", Task: 12345}
+	ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+	require.NoError(t, Cancel(ctx, ref, &config.BaseConfig))
+
+	data, err := os.ReadFile(config.Output)
+	require.NoError(t, err)
+
+	var cancelled CancelOutput
+	require.NoError(t, json.Unmarshal(data, &cancelled))
+	assert.Equal(t, "cancelled", cancelled.Status)
+	assert.Equal(t, ref, cancelled.TaskRef)
+}
+
+func TestAssessmentFlags(t *testing.T) {
+	cmd := AssessmentCommand(t.Context(), viper.New(), &internal.BaseConfig{})
+	for name, hasResultFlags := range map[string]bool{"status": true, "wait": true, "cancel": false} {
+		t.Run(name, func(t *testing.T) {
+			sub, _, err := cmd.Find([]string{name})
+			require.NoError(t, err)
+			for _, flag := range []string{"minimum-score", "poll-for-minutes", "baseline", "save-report"} {
+				assert.Equal(t, hasResultFlags, sub.Flags().Lookup(flag) != nil, flag)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package assessment
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/nowsecure/nowsecure-ci/cmd/ns/run"
+	"github.com/nowsecure/nowsecure-ci/internal"
+	"github.com/nowsecure/nowsecure-ci/internal/state"
+)
+
+func WaitCommand(c context.Context, v *viper.Viper, config *internal.BaseConfig) *cobra.Command {
+	waitCmd := &cobra.Command{
+		Use:   "wait",
+		Short: "Resume polling for an assessment recorded in a state file by ns run",
+		Example: `# Trigger an assessment in one stage...
+ns run file ./path/to/binary \
+  --group-ref YOUR_GROUP_UUID \
+  --poll-for-minutes 0
+
+# ...and gate on it in a later one
+ns assessment wait \
+  --state .ns-ci-state.json \
+  --poll-for-minutes 60 \
+  --minimum-score 70 \
+  --save-findings
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := run.BindResultFlags(v, cmd.Flags()); err != nil {
+				return err
+			}
+
+			statePath, err := cmd.Flags().GetString("state")
+			if err != nil {
+				return err
+			}
+
+			s, err := state.Load(statePath)
+			if err != nil {
+				return err
+			}
+
+			config, err := internal.NewRunConfig(v)
+			if err != nil {
+				return err
+			}
+			config.Platform = s.Platform
+			// An explicit group-ref takes precedence over the one the assessment was triggered with
+			if config.Group == uuid.Nil {
+				config.Group = s.Group
+			}
+			ctx := internal.LoggerWithLevel(config.LogLevel, config.Redactor).
+				WithContext(cmd.Context())
+
+			return Wait(ctx, TaskRef{Platform: s.Platform, Package: s.Package, Task: s.Task}, config)
+		},
+	}
+
+	waitCmd.Flags().String("state", state.DefaultFile, "state file written by ns run")
+	run.AddResultFlags(c, waitCmd.Flags(), config)
+
+	return waitCmd
+}
@@ -0,0 +1,84 @@
+package baseline
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/nowsecure/nowsecure-ci/internal"
+	nsbaseline "github.com/nowsecure/nowsecure-ci/internal/baseline"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+//revive:disable:exported
+func BaselineCommand(ctx context.Context, v *viper.Viper, config *internal.BaseConfig) *cobra.Command {
+	baselineCmd := &cobra.Command{
+		Use:   "baseline",
+		Short: "Manage baselines of accepted findings",
+	}
+
+	baselineCmd.AddCommand(
+		UpdateCommand(ctx, config),
+	)
+
+	return baselineCmd
+}
+
+func UpdateCommand(c context.Context, config *internal.BaseConfig) *cobra.Command {
+	updateCmd := &cobra.Command{
+		Use:   "update [baseline-file]",
+		Short: "Regenerate a baseline from the affected findings of a completed assessment",
+		Example: `# Accept every finding of task 12345
+ns baseline update ./findings-baseline.json \
+  --task 12345
+
+# Gate later runs on newly introduced findings only
+ns run file ./path/to/binary \
+  --group-ref YOUR_GROUP_UUID \
+  --baseline ./findings-baseline.json \
+  --fail-on-severity high
+`,
+		ValidArgs: []string{"baselineFile"},
+		Args:      cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			task, err := cmd.Flags().GetInt("task")
+			if err != nil {
+				return err
+			}
+			ctx := internal.LoggerWithLevel(config.LogLevel, config.Redactor).
+				WithContext(cmd.Context())
+
+			return Update(ctx, args[0], float64(task), config)
+		},
+	}
+
+	updateCmd.Flags().Int("task", 0, "task ID of the completed assessment to take the findings from")
+
+	if err := updateCmd.MarkFlagRequired("task"); err != nil {
+		zerolog.Ctx(c).Panic().Err(err).Msg("Failed marking baseline update flags")
+	}
+
+	return updateCmd
+}
+
+func Update(ctx context.Context, path string, task float64, config *internal.BaseConfig) error {
+	findings, err := platformapi.GetFindings(ctx, config.PlatformClient, task)
+	if err != nil {
+		return err
+	}
+
+	var items []platformapi.GetAssessmentTaskFindings_2XX_Item
+	if findings != nil {
+		items = *findings
+	}
+
+	b := nsbaseline.New(items, task)
+	if err := b.Save(path); err != nil {
+		return err
+	}
+
+	zerolog.Ctx(ctx).Info().Str("Path", path).Int("Findings", len(b.Findings)).Msg("Baseline updated")
+	return nil
+}
@@ -0,0 +1,92 @@
+package baseline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nowsecure/nowsecure-ci/internal"
+	nsbaseline "github.com/nowsecure/nowsecure-ci/internal/baseline"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+func getTestConfig(t *testing.T, doer *platformapi.TestRequestDoer) *internal.BaseConfig {
+	client, err := platformapi.ClientFromConfig(platformapi.Config{
+		Host:      "https://localhost:8080",
+		UserAgent: "test/1.0",
+		Token:     "token",
+	}, doer)
+	require.NoError(t, err)
+
+	return &internal.BaseConfig{
+		PlatformClient: client,
+		LogLevel:       zerolog.DebugLevel,
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Run("Baseline contains only affected findings", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := getTestConfig(t, doer)
+
+		findings := []platformapi.GetAssessmentTaskFindings_2XX_Item{
+			{Affected: true, CheckId: "affected_check", Title: "Affected Check", Severity: "high"},
+			{Affected: false, CheckId: "unaffected_check", Title: "Unaffected Check", Severity: "low"},
+		}
+		findingsBody, err := json.Marshal(findings)
+		require.NoError(t, err)
+
+		doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/assessment/12345/findings")
+		})).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(findingsBody)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		}, nil)
+
+		path := filepath.Join(t.TempDir(), "findings-baseline.json")
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, Update(ctx, path, 12345, config))
+
+		b, err := nsbaseline.Load(path)
+		require.NoError(t, err)
+		assert.InDelta(t, 12345, b.Task, 0)
+		require.Len(t, b.Findings, 1)
+		assert.Equal(t, "affected_check", b.Findings[0].CheckID)
+		assert.Equal(t, nsbaseline.Key(&findings[0]), b.Findings[0].Key)
+	})
+
+	t.Run("Platform errors are returned", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := getTestConfig(t, doer)
+
+		errorBody, err := json.Marshal(&platformapi.LabRouteError{
+			Message: platformapi.Ptr("Not found"),
+			Name:    platformapi.Ptr("NotFound"),
+			Status:  platformapi.Ptr("404"),
+		})
+		require.NoError(t, err)
+
+		doer.On("Do", mock.Anything).Return(&http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       io.NopCloser(bytes.NewReader(errorBody)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		}, nil)
+
+		path := filepath.Join(t.TempDir(), "findings-baseline.json")
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.Error(t, Update(ctx, path, 12345, config))
+		assert.NoFileExists(t, path)
+	})
+}
@@ -4,14 +4,21 @@ import (
 	"context"
 	"errors"
 	"os"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/nowsecure/nowsecure-ci/cmd/ns/app"
+	"github.com/nowsecure/nowsecure-ci/cmd/ns/assessment"
+	"github.com/nowsecure/nowsecure-ci/cmd/ns/baseline"
+	"github.com/nowsecure/nowsecure-ci/cmd/ns/report"
 	"github.com/nowsecure/nowsecure-ci/cmd/ns/run"
 	"github.com/nowsecure/nowsecure-ci/cmd/ns/version"
 	"github.com/nowsecure/nowsecure-ci/internal"
+	nserrors "github.com/nowsecure/nowsecure-ci/internal/errors"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
 )
 
 func RootCommand(ctx context.Context, v *viper.Viper, config *internal.BaseConfig) *cobra.Command {
@@ -53,7 +60,12 @@ func RootCommand(ctx context.Context, v *viper.Viper, config *internal.BaseConfi
 	rootCmd.PersistentFlags().String("group-ref", "", "group uuid with which to run assessments")
 	rootCmd.PersistentFlags().String("log-level", "info", "logging level")
 	rootCmd.PersistentFlags().StringP("output", "o", "", "write  output to <file> instead of stdout.")
-	rootCmd.PersistentFlags().String("output-format", "json", "write  output in specified format.")
+	rootCmd.PersistentFlags().String("output-format", "json", "write  output in specified format. One of: json, table, sarif, junit, markdown")
+	rootCmd.PersistentFlags().Int("max-retries", 3, "retries of failed idempotent API requests (connection errors, 429 and 5XX responses), 0 to disable")
+	rootCmd.PersistentFlags().Duration("retry-max-wait", 30*time.Second, "maximum wait between retries of an API request")
+	rootCmd.PersistentFlags().Bool("no-color", false, "disable colors in table output")
+	rootCmd.PersistentFlags().Bool("hide-sensitive-values", false, "hide app configuration secrets in assessment results and redact sensitive fields from output (default true when CI or ci-environment is set)")
+	rootCmd.PersistentFlags().StringArray("redact-pattern", nil, "regular expression of secrets to mask in logs and output, repeatable. The token is always masked")
 	rootCmd.PersistentFlags().String("ci-environment", "", "appended to the user_agent header")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "enable verbose logging (same as --log-level debug)")
 	bindingErrors := []error{
@@ -66,6 +78,11 @@ func RootCommand(ctx context.Context, v *viper.Viper, config *internal.BaseConfi
 		v.BindPFlag("output_format", rootCmd.PersistentFlags().Lookup("output-format")),
 		v.BindPFlag("log_level", rootCmd.PersistentFlags().Lookup("log-level")),
 		v.BindPFlag("ci_environment", rootCmd.PersistentFlags().Lookup("ci-environment")),
+		v.BindPFlag("no_color", rootCmd.PersistentFlags().Lookup("no-color")),
+		v.BindPFlag("hide_sensitive_values", rootCmd.PersistentFlags().Lookup("hide-sensitive-values")),
+		v.BindPFlag("redact_patterns", rootCmd.PersistentFlags().Lookup("redact-pattern")),
+		v.BindPFlag("max_retries", rootCmd.PersistentFlags().Lookup("max-retries")),
+		v.BindPFlag("retry_max_wait", rootCmd.PersistentFlags().Lookup("retry-max-wait")),
 		v.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose")),
 	}
 	if errs := errors.Join(bindingErrors...); errs != nil {
@@ -74,7 +91,13 @@ func RootCommand(ctx context.Context, v *viper.Viper, config *internal.BaseConfi
 
 	rootCmd.MarkFlagsMutuallyExclusive("log-level", "verbose")
 
-	rootCmd.AddCommand(run.RunCommand(ctx, v, config))
+	rootCmd.AddCommand(
+		run.RunCommand(ctx, v, config),
+		baseline.BaselineCommand(ctx, v, config),
+		assessment.AssessmentCommand(ctx, v, config),
+		report.ReportCommand(ctx, v, config),
+		app.AppCommand(ctx, v, config),
+	)
 
 	return rootCmd
 }
@@ -92,3 +115,20 @@ func readConfigFile(v *viper.Viper) error {
 	v.AddConfigPath(".")
 	return v.ReadInConfig()
 }
+
+// LogError logs the error a command failed with and returns the exit code of the failure. Secrets are masked with
+// the redactor of the configuration, which is set once the root command has parsed it
+func LogError(ctx context.Context, config *internal.BaseConfig, err error) int {
+	log := zerolog.Ctx(ctx).Output(internal.ConsoleLevelWriter{Redactor: config.Redactor})
+
+	if reqErr, ok := err.(*platformapi.LabRouteError); ok {
+		log.Error().Any("LabRouteError", reqErr).Msg("API Error Response")
+		return reqErr.ExitCode()
+	}
+	if ciErr, ok := err.(nserrors.CIError); ok {
+		log.Error().Msg(ciErr.Error())
+		return ciErr.ExitCode()
+	}
+	log.WithLevel(zerolog.FatalLevel).Msg(err.Error())
+	return 1
+}
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -19,6 +21,8 @@ import (
 	"github.com/nowsecure/nowsecure-ci/cmd/ns/version"
 	"github.com/nowsecure/nowsecure-ci/internal"
 	"github.com/nowsecure/nowsecure-ci/internal/output"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+	"github.com/nowsecure/nowsecure-ci/internal/redact"
 )
 
 func setupTest(t *testing.T) (*viper.Viper, *internal.BaseConfig, context.Context) {
@@ -40,6 +44,54 @@ func executeCommandC(root *cobra.Command, args ...string) (c *cobra.Command, out
 	return c, buf.String(), err
 }
 
+// captureStderr returns what f writes to stderr
+func captureStderr(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	f()
+	require.NoError(t, w.Close())
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestLogError(t *testing.T) {
+	t.Run("Token is masked in the error of a failed command", func(t *testing.T) {
+		v, config, ctx := setupTest(t)
+		ctx = zerolog.New(internal.ConsoleLevelWriter{}).WithContext(ctx)
+		binary := filepath.Join(t.TempDir(), "secret-token.apk")
+		_, _, err := executeCommandC(RootCommand(ctx, v, config), "--token", "secret-token", "run", "file", binary)
+		require.ErrorContains(t, err, "secret-token")
+
+		var code int
+		stderr := captureStderr(t, func() { code = LogError(ctx, config, err) })
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr, "[REDACTED].apk")
+		assert.NotContains(t, stderr, "secret-token")
+	})
+
+	t.Run("Token is masked in API error responses", func(t *testing.T) {
+		v, config, ctx := setupTest(t)
+		ctx = zerolog.New(internal.ConsoleLevelWriter{}).WithContext(ctx)
+		_, _, err := executeCommandC(RootCommand(ctx, v, config), "--token", "secret-token", "help")
+		require.NoError(t, err)
+
+		apiErr := &platformapi.LabRouteError{
+			Status:  platformapi.Ptr("401"),
+			Name:    platformapi.Ptr("Unauthorized"),
+			Message: platformapi.Ptr("invalid token secret-token"),
+		}
+		stderr := captureStderr(t, func() { LogError(ctx, config, apiErr) })
+		assert.Contains(t, stderr, "invalid token [REDACTED]")
+		assert.NotContains(t, stderr, "secret-token")
+	})
+}
+
 func TestCommandFromFlags(t *testing.T) {
 	groupRef := uuid.New()
 	token := "test-token"
@@ -122,6 +174,159 @@ func TestCommandFromFlags(t *testing.T) {
 		_, _, err := executeCommandC(rootCmd, "--config", "./some/bad/path", "help")
 		require.ErrorContains(t, err, "no such file or directory")
 	})
+
+	t.Run("Sensitive values are hidden by default in CI", func(t *testing.T) {
+		assessmentConfig := map[string]any{"password": "hunter2"}
+
+		t.Setenv("CI", "")
+		v, config, ctx := setupTest(t)
+		_, _, err := executeCommandC(RootCommand(ctx, v, config), "--token", "some-token", "help")
+		require.NoError(t, err)
+		assert.False(t, config.HideSensitive)
+
+		t.Setenv("CI", "true")
+		v, config, ctx = setupTest(t)
+		_, _, err = executeCommandC(RootCommand(ctx, v, config), "--token", "some-token", "help")
+		require.NoError(t, err)
+		assert.True(t, config.HideSensitive)
+		redacted, err := config.Redactor.Value(assessmentConfig)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"password": redact.Mask}, redacted)
+
+		v, config, ctx = setupTest(t)
+		_, _, err = executeCommandC(RootCommand(ctx, v, config), "--token", "some-token", "--hide-sensitive-values=false", "help")
+		require.NoError(t, err)
+		assert.False(t, config.HideSensitive)
+		redacted, err = config.Redactor.Value(assessmentConfig)
+		require.NoError(t, err)
+		assert.Equal(t, assessmentConfig, redacted)
+	})
+
+	t.Run("No color is passed to output writers", func(t *testing.T) {
+		v, config, ctx := setupTest(t)
+		_, _, err := executeCommandC(RootCommand(ctx, v, config), "--token", "some-token", "--no-color", "help")
+		require.NoError(t, err)
+		assert.True(t, config.OutputOptions().NoColor)
+	})
+
+	t.Run("Token and redact patterns are masked in logs and output", func(t *testing.T) {
+		v, config, ctx := setupTest(t)
+		_, _, err := executeCommandC(RootCommand(ctx, v, config), "--token", "secret-token",
+			"--redact-pattern", "build-key-[0-9]+", "--redact-pattern", "x{1,3},y", "help")
+		require.NoError(t, err)
+		assert.Same(t, config.Redactor, config.OutputOptions().Redactor)
+
+		masked := config.Redactor.String("Bearer secret-token with build-key-1234 and xx,y")
+		assert.Equal(t, "Bearer [REDACTED] with [REDACTED] and [REDACTED]", masked)
+
+		event := config.Redactor.JSON([]byte(`{"level":"debug","Query":"key=build-key-42&page=1","message":"Platform request"}` + "\n"))
+		assert.JSONEq(t, `{"level":"debug","Query":"key=[REDACTED]&page=1","message":"Platform request"}`, string(event))
+	})
+
+	t.Run("Invalid redact pattern throws error", func(t *testing.T) {
+		v, config, ctx := setupTest(t)
+		_, _, err := executeCommandC(RootCommand(ctx, v, config), "--token", "some-token", "--redact-pattern", "(unclosed", "help")
+		require.ErrorContains(t, err, `invalid redact pattern "(unclosed"`)
+	})
+
+	t.Run("Build version of run file doesn't shadow the CLI version", func(t *testing.T) {
+		v, config, ctx := setupTest(t)
+		rootCmd := RootCommand(ctx, v, config)
+		_, _, err := executeCommandC(rootCmd, "--token", "some-token", "run", "file", "./app.apk", "--version", "1.2.3-nightly", "--help")
+		require.NoError(t, err)
+		assert.Equal(t, "1.2.3-nightly", v.GetString("build_version"))
+
+		v, config, ctx = setupTest(t)
+		rootCmd = RootCommand(ctx, v, config)
+		_, out, err := executeCommandC(rootCmd, "--version")
+		require.NoError(t, err)
+		assert.Contains(t, out, version.Version())
+	})
+
+	t.Run("Dry run requires an app configuration", func(t *testing.T) {
+		v, config, ctx := setupTest(t)
+		rootCmd := RootCommand(ctx, v, config)
+		_, _, err := executeCommandC(rootCmd, "--token", "some-token", "run", "package", "com.example.app", "--android", "--dry-run")
+		require.ErrorContains(t, err, "cannot set dry-run without setting app-config")
+	})
+
+	t.Run("State file is only written by default without polling", func(t *testing.T) {
+		runConfig := func(t *testing.T, args ...string) *internal.RunConfig {
+			v, config, ctx := setupTest(t)
+			args = append([]string{"--token", "some-token", "run", "package", "com.example.app", "--android"}, args...)
+			_, _, err := executeCommandC(RootCommand(ctx, v, config), append(args, "--help")...)
+			require.NoError(t, err)
+
+			runConfig, err := internal.NewRunConfig(v)
+			require.NoError(t, err)
+			return runConfig
+		}
+
+		assert.Empty(t, runConfig(t).StateFile)
+		assert.Equal(t, ".ns-ci-state.json", runConfig(t, "--poll-for-minutes", "0").StateFile)
+		assert.Equal(t, "stage.json", runConfig(t, "--state-file", "stage.json").StateFile)
+		assert.Empty(t, runConfig(t, "--poll-for-minutes", "0", "--state-file", "").StateFile)
+	})
+
+	t.Run("Appstore is read from the flag, environment and config file", func(t *testing.T) {
+		appstore := func(t *testing.T, args ...string) bool {
+			v, config, ctx := setupTest(t)
+			// Nothing listens on the API host, the run fails once its configuration is read
+			args = append([]string{"--token", "some-token", "--api-host", "http://127.0.0.1:1", "--max-retries", "0"}, args...)
+			_, _, err := executeCommandC(RootCommand(ctx, v, config), args...)
+			require.Error(t, err)
+
+			runConfig, err := internal.NewRunConfig(v)
+			require.NoError(t, err)
+			return runConfig.Appstore
+		}
+
+		assert.False(t, appstore(t, "run", "package", "com.example.app", "--android"))
+		assert.True(t, appstore(t, "run", "package", "com.example.app", "--android", "--appstore"))
+		assert.True(t, appstore(t, "run", "id", uuid.NewString(), "--appstore"))
+
+		configFile := filepath.Join(t.TempDir(), ".ns-ci.yaml")
+		require.NoError(t, os.WriteFile(configFile, []byte("appstore: true\n"), 0o600))
+		assert.True(t, appstore(t, "--config", configFile, "run", "id", uuid.NewString()))
+
+		t.Setenv("NS_APPSTORE", "true")
+		assert.True(t, appstore(t, "run", "package", "com.example.app", "--ios"))
+	})
+
+	t.Run("Batch manifest and concurrency are read from the flag, environment and config file", func(t *testing.T) {
+		batchRun := func(t *testing.T, args ...string) (*viper.Viper, error) {
+			v, config, ctx := setupTest(t)
+			args = append([]string{"--token", "some-token"}, args...)
+			_, _, err := executeCommandC(RootCommand(ctx, v, config), append(args, "run", "batch")...)
+			return v, err
+		}
+
+		_, err := batchRun(t)
+		require.ErrorContains(t, err, "a manifest is required")
+
+		missing := filepath.Join(t.TempDir(), "apps.yaml")
+		_, err = batchRun(t, "--manifest", missing)
+		require.ErrorContains(t, err, missing)
+
+		configFile := filepath.Join(t.TempDir(), ".ns-ci.yaml")
+		require.NoError(t, os.WriteFile(configFile, []byte("manifest: "+missing+"\nconcurrency: 2\n"), 0o600))
+		v, err := batchRun(t, "--config", configFile)
+		require.ErrorContains(t, err, missing)
+		assert.Equal(t, 2, v.GetInt("concurrency"))
+
+		t.Setenv("NS_MANIFEST", missing)
+		t.Setenv("NS_CONCURRENCY", "3")
+		v, err = batchRun(t)
+		require.ErrorContains(t, err, missing)
+		assert.Equal(t, 3, v.GetInt("concurrency"))
+	})
+
+	t.Run("Version and version from git cannot both be set", func(t *testing.T) {
+		v, config, ctx := setupTest(t)
+		rootCmd := RootCommand(ctx, v, config)
+		_, _, err := executeCommandC(rootCmd, "--token", "some-token", "run", "file", "./app.apk", "--version", "1.2.3", "--version-from-git")
+		require.ErrorContains(t, err, "if any flags in the group [version version-from-git] are set none of the others can be")
+	})
 }
 
 func TestCommandFromEnvVars(t *testing.T) {
@@ -0,0 +1,88 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/nowsecure/nowsecure-ci/internal"
+	"github.com/nowsecure/nowsecure-ci/internal/output"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+//revive:disable:exported
+func ReportCommand(ctx context.Context, v *viper.Viper, config *internal.BaseConfig) *cobra.Command {
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Download assessment reports",
+	}
+
+	reportCmd.AddCommand(
+		DownloadCommand(ctx, v, config),
+	)
+
+	return reportCmd
+}
+
+func DownloadCommand(c context.Context, v *viper.Viper, config *internal.BaseConfig) *cobra.Command {
+	pwd, err := os.Getwd()
+	if err != nil {
+		zerolog.Ctx(c).Panic().Err(err).Msg("Failed to get present working directory")
+	}
+
+	downloadCmd := &cobra.Command{
+		Use:   "download [assessment-ref]",
+		Short: "Download the reports of a completed assessment",
+		Example: `# Download the PDF report of an assessment
+ns report download aaaaaaaa-1111-bbbb-2222-cccccccccccc
+
+# Download every report format into a directory
+ns report download aaaaaaaa-1111-bbbb-2222-cccccccccccc \
+  --format pdf,html,json \
+  --artifacts-dir ./reports
+`,
+		ValidArgs: []string{"assessmentRef"},
+		Args:      cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ref, err := uuid.Parse(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid assessment ref: %w", err)
+			}
+
+			// artifacts_dir is shared with the run commands, so the flags are bound once this command is known to run
+			if err := bindDownloadFlags(v, cmd.Flags()); err != nil {
+				return err
+			}
+
+			formats, err := platformapi.ParseReportFormats(v.GetString("format"))
+			if err != nil {
+				return err
+			}
+
+			ctx := internal.LoggerWithLevel(config.LogLevel, config.Redactor).
+				WithContext(cmd.Context())
+
+			return output.SaveReports(ctx, config.PlatformClient, ref, formats, v.GetString("artifacts_dir"))
+		},
+	}
+
+	downloadCmd.Flags().String("format", string(platformapi.ReportPDF), "comma separated report formats to download. Any of: pdf, html, json")
+	downloadCmd.Flags().String("artifacts-dir", pwd, "directory in which to put the reports")
+
+	return downloadCmd
+}
+
+// bindDownloadFlags binds the flags of ns report download
+func bindDownloadFlags(v *viper.Viper, flags *pflag.FlagSet) error {
+	return errors.Join(
+		v.BindPFlag("format", flags.Lookup("format")),
+		v.BindPFlag("artifacts_dir", flags.Lookup("artifacts-dir")),
+	)
+}
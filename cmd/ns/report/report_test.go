@@ -0,0 +1,103 @@
+package report
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nowsecure/nowsecure-ci/internal"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+func getTestClient(t *testing.T, doer *platformapi.TestRequestDoer) platformapi.ClientWithResponsesInterface {
+	client, err := platformapi.ClientFromConfig(platformapi.Config{
+		Host:      "https://localhost:8080",
+		UserAgent: "test/1.0",
+		Token:     "token",
+	}, doer)
+	require.NoError(t, err)
+
+	return client
+}
+
+func useReport(doer *platformapi.TestRequestDoer, ref uuid.UUID, format string, statusCode int, body string) {
+	doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/assessment/"+ref.String()+"/report."+format)
+	})).Return(&http.Response{
+		StatusCode: statusCode,
+		Status:     http.StatusText(statusCode),
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Header:     http.Header{"Content-Type": []string{"application/octet-stream"}},
+	}, nil)
+}
+
+func TestDownload(t *testing.T) {
+	ref := uuid.New()
+
+	t.Run("Formats and directory are read from the environment", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		dir := filepath.Join(t.TempDir(), "reports")
+		t.Setenv("NS_FORMAT", "html,json")
+		t.Setenv("NS_ARTIFACTS_DIR", dir)
+
+		useReport(doer, ref, "html", http.StatusOK, "<html></html>")
+		useReport(doer, ref, "json", http.StatusOK, `{"score":85}`)
+
+		v := viper.New()
+		v.SetEnvPrefix("NS")
+		v.AutomaticEnv()
+		config := &internal.BaseConfig{PlatformClient: getTestClient(t, doer)}
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		cmd := DownloadCommand(ctx, v, config)
+		cmd.SetArgs([]string{ref.String()})
+		require.NoError(t, cmd.ExecuteContext(ctx))
+
+		assert.FileExists(t, filepath.Join(dir, "report.html"))
+		assert.FileExists(t, filepath.Join(dir, "report.json"))
+		assert.NoFileExists(t, filepath.Join(dir, "report.pdf"))
+	})
+
+	t.Run("Flags take precedence over the environment", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		dir := t.TempDir()
+		t.Setenv("NS_FORMAT", "html")
+		t.Setenv("NS_ARTIFACTS_DIR", filepath.Join(t.TempDir(), "ignored"))
+
+		useReport(doer, ref, "pdf", http.StatusOK, "%PDF-1.7")
+
+		v := viper.New()
+		v.SetEnvPrefix("NS")
+		v.AutomaticEnv()
+		config := &internal.BaseConfig{PlatformClient: getTestClient(t, doer)}
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		cmd := DownloadCommand(ctx, v, config)
+		cmd.SetArgs([]string{ref.String(), "--format", "pdf", "--artifacts-dir", dir})
+		require.NoError(t, cmd.ExecuteContext(ctx))
+
+		pdf, err := os.ReadFile(filepath.Join(dir, "report.pdf"))
+		require.NoError(t, err)
+		assert.Equal(t, "%PDF-1.7", string(pdf))
+	})
+}
+
+func TestParseReportFormats(t *testing.T) {
+	formats, err := platformapi.ParseReportFormats("PDF, html,pdf")
+	require.NoError(t, err)
+	assert.Equal(t, []platformapi.ReportFormat{platformapi.ReportPDF, platformapi.ReportHTML}, formats)
+
+	_, err = platformapi.ParseReportFormats("pdf,docx")
+	require.ErrorContains(t, err, "docx")
+}
@@ -0,0 +1,269 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/nowsecure/nowsecure-ci/internal"
+	"github.com/nowsecure/nowsecure-ci/internal/batch"
+	nserrors "github.com/nowsecure/nowsecure-ci/internal/errors"
+	"github.com/nowsecure/nowsecure-ci/internal/output"
+)
+
+func BatchCommand(c context.Context, v *viper.Viper, config *internal.BaseConfig) *cobra.Command {
+	batchCmd := &cobra.Command{
+		Use:   "batch",
+		Short: "Run assessments for every app listed in a manifest file",
+		Example: `# Assess every app of a monorepo, four at a time
+ns run batch \
+  --manifest ./apps.yaml \
+  --group-ref YOUR_GROUP_UUID \
+  --concurrency 4 \
+  --minimum-score 70
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The manifest may also come from NS_MANIFEST or the config file, so it isn't a required flag
+			manifestPath := v.GetString("manifest")
+			if manifestPath == "" {
+				return errors.New("a manifest is required, set it with --manifest")
+			}
+
+			manifest, err := batch.Load(manifestPath)
+			if err != nil {
+				return err
+			}
+
+			config, err := internal.NewRunConfig(v)
+			if err != nil {
+				return err
+			}
+			ctx := internal.LoggerWithLevel(config.LogLevel, config.Redactor).
+				WithContext(cmd.Context())
+
+			return ByManifest(ctx, manifest, v.GetInt("concurrency"), config)
+		},
+	}
+
+	batchCmd.Flags().String("manifest", "", "manifest file listing the apps to assess")
+	batchCmd.Flags().Int("concurrency", 4, "maximum number of apps assessed at the same time")
+
+	bindingErrors := []error{
+		v.BindPFlag("manifest", batchCmd.Flags().Lookup("manifest")),
+		v.BindPFlag("concurrency", batchCmd.Flags().Lookup("concurrency")),
+	}
+
+	if errs := errors.Join(bindingErrors...); errs != nil {
+		zerolog.Ctx(c).Panic().Err(errs).Msg("Failed binding batch flags")
+	}
+
+	return batchCmd
+}
+
+type BatchResult struct {
+	Name     string `json:"name"`
+	Passed   bool   `json:"passed"`
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+	Output   string `json:"output"`
+	// Assessment is nil when the app failed before an assessment was triggered
+	Assessment *AssessmentResult `json:"assessment,omitempty"`
+}
+
+// AssessmentResult identifies the assessment of an app, with its score once it has completed
+type AssessmentResult struct {
+	Platform string   `json:"platform"`
+	Package  string   `json:"package"`
+	Task     float64  `json:"task"`
+	URL      string   `json:"url"`
+	Score    *float32 `json:"score,omitempty"`
+}
+
+type BatchOutput struct {
+	Passed bool          `json:"passed"`
+	Apps   []BatchResult `json:"apps"`
+}
+
+// ByManifest runs the assessment of every app in a manifest with at most concurrency running at once. The output
+// and artifacts of each app are written to its own directory under the artifacts directory, and the aggregate
+// result to the configured output
+func ByManifest(ctx context.Context, manifest *batch.Manifest, concurrency int, config *internal.RunConfig) error {
+	if concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1")
+	}
+
+	for _, app := range manifest.Apps {
+		if app.SeverityGate != nil && app.SeverityGate.Enabled() && config.PollForMinutes <= 0 {
+			return fmt.Errorf("%s: cannot set fail_on_severity or max_findings without setting a nonzero poll-for-minutes", app.Name)
+		}
+	}
+
+	w, err := aggregateWriter(config)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	results := runApps(ctx, manifest.Apps, concurrency, config)
+	failed := failedResults(results)
+	aggregate := BatchOutput{Passed: len(failed) == 0, Apps: results}
+
+	if err := w.Write(aggregate); err != nil {
+		return err
+	}
+
+	if len(failed) > 0 {
+		return &BatchError{Failed: failed}
+	}
+
+	zerolog.Ctx(ctx).Info().Int("Apps", len(results)).Msg("Succeeded")
+	return nil
+}
+
+// aggregateWriter writes the combined result of several assessments. Formats rendered from a single
+// assessment fall back to JSON
+func aggregateWriter(config *internal.RunConfig) (*output.CLIWriter, error) {
+	format := config.OutputFormat
+	if format.RequiresFindings() {
+		format = output.JSON
+	}
+
+	return output.New(config.Output, format, config.OutputOptions())
+}
+
+// runApps runs the assessment of every app with at most concurrency running at once
+func runApps(ctx context.Context, apps []batch.App, concurrency int, config *internal.RunConfig) []BatchResult {
+	results := make([]BatchResult, len(apps))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range apps {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			results[i] = runApp(ctx, &apps[i], config)
+		}(i)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func failedResults(results []BatchResult) []BatchResult {
+	var failed []BatchResult
+	for _, result := range results {
+		if !result.Passed {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
+
+func runApp(ctx context.Context, app *batch.App, config *internal.RunConfig) BatchResult {
+	log := zerolog.Ctx(ctx).With().Str("App", app.Name).Logger()
+	ctx = log.WithContext(ctx)
+
+	appConfig, err := appRunConfig(app, config)
+	result := BatchResult{Name: app.Name, Passed: true, Output: appConfig.Output}
+	if err == nil {
+		switch {
+		case app.File != "":
+			result.Assessment, err = byFile(ctx, app.File, appConfig)
+		case app.Package != "":
+			result.Assessment, err = byPackage(ctx, app.Package, appConfig)
+		default:
+			result.Assessment, err = byID(ctx, app.AppID, appConfig)
+		}
+	}
+
+	if err != nil {
+		result.Passed = false
+		result.Error = err.Error()
+		result.ExitCode = 1
+		var ciErr nserrors.CIError
+		if errors.As(err, &ciErr) {
+			result.ExitCode = ciErr.ExitCode()
+		}
+		log.Error().Err(err).Msg("Failed")
+	}
+
+	return result
+}
+
+// appRunConfig copies the run configuration for an app, applying its overrides and moving its output and
+// artifacts into a directory of its own so that concurrent runs don't overwrite each other
+func appRunConfig(app *batch.App, config *internal.RunConfig) (*internal.RunConfig, error) {
+	appConfig := *config
+	dir := filepath.Join(config.ArtifactsDir, app.Name)
+	appConfig.ArtifactsDir = dir
+	appConfig.Output = filepath.Join(dir, "output."+config.OutputFormat.Extension())
+	if config.FindingsArtifactPath != "" {
+		appConfig.FindingsArtifactPath = filepath.Join(dir, "findings.json")
+		appConfig.SARIFArtifactPath = filepath.Join(dir, "findings.sarif")
+	}
+	if config.StateFile != "" {
+		appConfig.StateFile = filepath.Join(dir, filepath.Base(config.StateFile))
+	}
+
+	if app.File == "" {
+		// Only uploads carry a version
+		appConfig.BuildVersion = ""
+	}
+	if app.Platform != "" {
+		appConfig.Platform = app.Platform
+	}
+	appConfig.Appstore = app.Appstore
+	if app.AppConfig != "" {
+		appConfig.AppConfig = app.AppConfig
+	}
+	if app.AnalysisType != "" {
+		appConfig.AnalysisType = app.AnalysisType
+	}
+	if app.MinimumScore != nil {
+		appConfig.MinimumScore = *app.MinimumScore
+	}
+	if app.SeverityGate != nil {
+		appConfig.SeverityGate = *app.SeverityGate
+	}
+
+	return &appConfig, os.MkdirAll(dir, os.ModePerm)
+}
+
+var _ nserrors.CIError = (*BatchError)(nil)
+
+type BatchError struct {
+	Failed []BatchResult
+}
+
+func (e *BatchError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d app(s) failed:", len(e.Failed))
+	for _, result := range e.Failed {
+		fmt.Fprintf(&b, "\n  - %s: %s", result.Name, strings.ReplaceAll(result.Error, "\n", "\n    "))
+	}
+
+	return b.String()
+}
+
+// ExitCode is the highest exit code of the failed apps, so that a gate failure in any app is not masked
+// by a plain error in another
+func (e *BatchError) ExitCode() int {
+	code := 1
+	for _, result := range e.Failed {
+		code = max(code, result.ExitCode)
+	}
+
+	return code
+}
@@ -0,0 +1,297 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nowsecure/nowsecure-ci/internal/batch"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+// batchDoer answers every request with a fresh response, as the concurrently assessed apps share it
+type batchDoer struct {
+	trigger    []byte
+	assessment []byte
+}
+
+func (d *batchDoer) Do(req *http.Request) (*http.Response, error) {
+	body := d.assessment
+	if req.Method == http.MethodPost {
+		body = d.trigger
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}, nil
+}
+
+func newBatchDoer(t *testing.T, trigger *TriggerAssessmentResponse, assessment *GetAssessmentResponse) *batchDoer {
+	triggerBody, err := json.Marshal(trigger)
+	require.NoError(t, err)
+	assessmentBody, err := json.Marshal(assessment)
+	require.NoError(t, err)
+
+	return &batchDoer{trigger: triggerBody, assessment: assessmentBody}
+}
+
+func TestByManifest(t *testing.T) {
+	appID := uuid.New()
+	completedStatus := platformapi.GetAppPlatformPackageAssessmentTask2XXTaskStatus("completed")
+
+	writeManifest := func(t *testing.T, manifest string) *batch.Manifest {
+		path := filepath.Join(t.TempDir(), "apps.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(manifest), 0o644))
+		m, err := batch.Load(path)
+		require.NoError(t, err)
+		return m
+	}
+
+	t.Run("Per-app thresholds produce an aggregate result", func(t *testing.T) {
+		config := GetTestConfig(t, &platformapi.TestRequestDoer{})
+		config.PollForMinutes = 1
+		config.ArtifactsDir = t.TempDir()
+		config.Output = filepath.Join(t.TempDir(), "batch.json")
+
+		manifest := writeManifest(t, `
+apps:
+  - name: lenient
+    package: com.example.lenient
+    platform: android
+    minimum_score: 50
+  - package: com.example.strict
+    platform: ios
+    minimum_score: 90
+`)
+
+		doer := newBatchDoer(t, &TriggerAssessmentResponse{
+			Application: appID,
+			Package:     "com.example",
+			Platform:    "android",
+			Task:        12345,
+			Ref:         appID,
+		}, &GetAssessmentResponse{
+			Application:   &appID,
+			Package:       "com.example",
+			Platform:      "android",
+			Task:          12345,
+			Ref:           appID,
+			TaskStatus:    &completedStatus,
+			AdjustedScore: platformapi.Ptr(float32(72.5)),
+		})
+		client, err := platformapi.ClientFromConfig(platformapi.Config{Host: config.APIHost}, doer)
+		require.NoError(t, err)
+		config.PlatformClient = client
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err = ByManifest(ctx, manifest, 2, config)
+
+		var batchErr *BatchError
+		require.ErrorAs(t, err, &batchErr)
+		assert.Equal(t, 1, batchErr.ExitCode())
+		require.Len(t, batchErr.Failed, 1)
+		assert.Equal(t, "com.example.strict-ios", batchErr.Failed[0].Name)
+
+		data, err := os.ReadFile(config.Output)
+		require.NoError(t, err)
+
+		var aggregate BatchOutput
+		require.NoError(t, json.Unmarshal(data, &aggregate))
+		assert.False(t, aggregate.Passed)
+		require.Len(t, aggregate.Apps, 2)
+		assert.Equal(t, "lenient", aggregate.Apps[0].Name)
+		assert.True(t, aggregate.Apps[0].Passed)
+		assert.False(t, aggregate.Apps[1].Passed)
+		assert.Contains(t, aggregate.Apps[1].Error, "less than the required minimum 90")
+
+		assert.FileExists(t, filepath.Join(config.ArtifactsDir, "lenient", "output.json"))
+		assert.FileExists(t, filepath.Join(config.ArtifactsDir, "com.example.strict-ios", "output.json"))
+	})
+
+	t.Run("Gate failures take precedence in the exit code", func(t *testing.T) {
+		err := &BatchError{Failed: []BatchResult{{Name: "a", ExitCode: 1}, {Name: "b", ExitCode: 2}}}
+		assert.Equal(t, 2, err.ExitCode())
+	})
+
+	t.Run("Manifest entries must select exactly one app", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "apps.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`
+apps:
+  - package: com.example
+    file: ./app.apk
+  - package: com.example.other
+    platform: windows
+  - id: not-a-uuid
+  - file: ./app.ipa
+    appstore: true
+`), 0o644))
+
+		_, err := batch.Load(path)
+		require.ErrorContains(t, err, "exactly one of file, package or id")
+		require.ErrorContains(t, err, "platform must be one of")
+		require.ErrorContains(t, err, "invalid id")
+		require.ErrorContains(t, err, "app.ipa: appstore applies to package and id entries only")
+	})
+
+	t.Run("App store and candidate builds of a package can be compared", func(t *testing.T) {
+		m := writeManifest(t, `
+apps:
+  - package: com.example
+    platform: ios
+  - package: com.example
+    platform: ios
+    appstore: true
+`)
+		require.Len(t, m.Apps, 2)
+		assert.Equal(t, "com.example-ios", m.Apps[0].Name)
+		assert.Equal(t, "com.example-ios-appstore", m.Apps[1].Name)
+
+		config := GetTestConfig(t, &platformapi.TestRequestDoer{})
+		config.ArtifactsDir = t.TempDir()
+		config, err := appRunConfig(&m.Apps[1], config)
+		require.NoError(t, err)
+		assert.True(t, config.Appstore)
+	})
+
+	t.Run("App configuration of an entry overrides the command line and is relative to the manifest", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "apps.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`
+apps:
+  - package: com.example
+    platform: ios
+    app_config: ./nowsecure/ios-config.yaml
+  - package: com.example
+    platform: android
+`), 0o644))
+		m, err := batch.Load(path)
+		require.NoError(t, err)
+
+		config := GetTestConfig(t, &platformapi.TestRequestDoer{})
+		config.ArtifactsDir = t.TempDir()
+		config.AppConfig = "./app-config.yaml"
+
+		iosConfig, err := appRunConfig(&m.Apps[0], config)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(filepath.Dir(path), "nowsecure", "ios-config.yaml"), iosConfig.AppConfig)
+
+		androidConfig, err := appRunConfig(&m.Apps[1], config)
+		require.NoError(t, err)
+		assert.Equal(t, "./app-config.yaml", androidConfig.AppConfig)
+	})
+}
+
+func TestByFiles(t *testing.T) {
+	appID := uuid.New()
+	completedStatus := platformapi.GetAppPlatformPackageAssessmentTask2XXTaskStatus("completed")
+
+	t.Run("A failing platform doesn't hide the results of the other", func(t *testing.T) {
+		config := GetTestConfig(t, &platformapi.TestRequestDoer{})
+		config.PollForMinutes = 1
+		config.ArtifactsDir = t.TempDir()
+		config.Output = filepath.Join(t.TempDir(), "results.json")
+
+		doer := newBatchDoer(t, &TriggerAssessmentResponse{
+			Application: appID,
+			Package:     "com.example",
+			Platform:    "android",
+			Task:        12345,
+			Ref:         appID,
+		}, &GetAssessmentResponse{
+			Application:   &appID,
+			Package:       "com.example",
+			Platform:      "android",
+			Task:          12345,
+			Ref:           appID,
+			TaskStatus:    &completedStatus,
+			AdjustedScore: platformapi.Ptr(float32(72.5)),
+		})
+		client, err := platformapi.ClientFromConfig(platformapi.Config{Host: config.APIHost}, doer)
+		require.NoError(t, err)
+		config.PlatformClient = client
+
+		apk := filepath.Join(t.TempDir(), "app-release.apk")
+		writeTestAPK(t, apk, "com.example", "1.0.0")
+		missingIPA := filepath.Join(t.TempDir(), "app.ipa")
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err = ByFiles(ctx, []string{apk, missingIPA}, config)
+
+		var batchErr *BatchError
+		require.ErrorAs(t, err, &batchErr)
+		require.Len(t, batchErr.Failed, 1)
+		assert.Equal(t, "ios", batchErr.Failed[0].Name)
+
+		data, err := os.ReadFile(config.Output)
+		require.NoError(t, err)
+
+		var aggregate FilesOutput
+		require.NoError(t, json.Unmarshal(data, &aggregate))
+		assert.False(t, aggregate.Passed)
+		require.Contains(t, aggregate.Platforms, "android")
+		require.Contains(t, aggregate.Platforms, "ios")
+		assert.True(t, aggregate.Platforms["android"].Passed)
+		assert.False(t, aggregate.Platforms["ios"].Passed)
+		assert.Contains(t, aggregate.Platforms["ios"].Error, "no such file")
+		assert.Nil(t, aggregate.Platforms["ios"].Assessment)
+
+		android := aggregate.Platforms["android"].Assessment
+		require.NotNil(t, android)
+		assert.Equal(t, "com.example", android.Package)
+		assert.InDelta(t, 12345, android.Task, 0)
+		assert.Equal(t, platformapi.Ptr(float32(72.5)), android.Score)
+		assert.Equal(t, "https://localhost:8081/app/"+appID.String()+"/assessment/"+appID.String(), android.URL)
+		assert.FileExists(t, filepath.Join(config.ArtifactsDir, "android", "output.json"))
+	})
+
+	t.Run("Files of the same name are reported separately", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.ArtifactsDir = t.TempDir()
+		config.Output = filepath.Join(t.TempDir(), "results.json")
+
+		dir := t.TempDir()
+		files := []string{filepath.Join(dir, "a", "app.aab"), filepath.Join(dir, "b", "app.aab"), filepath.Join(dir, "c", "app.aab")}
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		var batchErr *BatchError
+		require.ErrorAs(t, ByFiles(ctx, files, config), &batchErr)
+
+		data, err := os.ReadFile(config.Output)
+		require.NoError(t, err)
+
+		var aggregate FilesOutput
+		require.NoError(t, json.Unmarshal(data, &aggregate))
+		assert.Len(t, aggregate.Platforms, 3)
+		for _, name := range []string{"android", "app.aab", "app.aab-3"} {
+			require.Contains(t, aggregate.Platforms, name)
+			assert.Contains(t, aggregate.Platforms[name].Error, "no such file")
+		}
+		doer.AssertNotCalled(t, "Do", mock.Anything)
+	})
+
+	t.Run("Package guard is rejected with more than one file", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.PackageName = "com.example"
+		config.Output = filepath.Join(t.TempDir(), "results.json")
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err := ByFiles(ctx, []string{"app.apk", "app.ipa"}, config)
+		require.ErrorContains(t, err, "cannot set package with more than one file")
+		assert.NoFileExists(t, config.Output)
+		doer.AssertNotCalled(t, "Do", mock.Anything)
+	})
+}
@@ -2,22 +2,26 @@ package run
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"time"
+	"path/filepath"
+	"strings"
 
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/nowsecure/nowsecure-ci/internal"
+	"github.com/nowsecure/nowsecure-ci/internal/appbinary"
+	"github.com/nowsecure/nowsecure-ci/internal/batch"
 	"github.com/nowsecure/nowsecure-ci/internal/output"
 	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
 )
 
-func FileCommand(v *viper.Viper, config *internal.BaseConfig) *cobra.Command {
-	return &cobra.Command{
-		Use:   "file [./file-path]",
+func FileCommand(c context.Context, v *viper.Viper, config *internal.BaseConfig) *cobra.Command {
+	fileCmd := &cobra.Command{
+		Use:   "file [./file-path]...",
 		Short: "Upload and run an assessment for a specified binary file",
 		Example: `# Common flags
 ns run file ./path/to/binary \
@@ -36,84 +40,325 @@ ns run file ./path/to/binary \
   --group-ref YOUR_GROUP_UUID \
   --poll-for-minutes 60
 
+# Run assessments of the Android and iOS builds of a release together
+ns run file ./path/to/app.apk ./path/to/app.ipa \
+  --group-ref YOUR_GROUP_UUID \
+  --poll-for-minutes 60
+
 # Run an assessment with a score threshold
 ns run file ./path/to/binary \
   --analysis-type static \
   --minimum-score 70 \
   --poll-for-minutes 60 \
   --group-ref YOUR_GROUP_UUID
+
+# Record the git tag or commit as the version of the build
+ns run file ./path/to/binary \
+  --version-from-git \
+  --group-ref YOUR_GROUP_UUID
+
+# Reject the binary before uploading it if it isn't a build of the expected package
+ns run file ./path/to/app.apk \
+  --package com.example.app \
+  --group-ref YOUR_GROUP_UUID
+
+# Gate on the results of an earlier assessment of an identical binary instead of uploading it again
+ns run file ./path/to/app.apk \
+  --reuse-existing \
+  --poll-for-minutes 60 \
+  --group-ref YOUR_GROUP_UUID
 `,
 		ValidArgs: []string{"file"},
 		Args:      cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fileName := args[0]
 			config, err := internal.NewRunConfig(v)
 			if err != nil {
 				return err
 			}
-			ctx := internal.LoggerWithLevel(config.LogLevel).
+			ctx := internal.LoggerWithLevel(config.LogLevel, config.Redactor).
 				WithContext(cmd.Context())
 
-			return ByFile(ctx, fileName, config)
+			if len(args) > 1 {
+				return ByFiles(ctx, args, config)
+			}
+			return ByFile(ctx, args[0], config)
 		},
 	}
+
+	fileCmd.Flags().String("package", "", "expected package name or bundle ID of the binary, a binary of any other package is rejected before upload. Only with a single file")
+	fileCmd.Flags().Bool("reuse-existing", false, "reuse the results of a completed assessment of an identical binary (by SHA-256) instead of uploading it")
+	fileCmd.Flags().String("version", "", "version to record for the uploaded build instead of the version in its metadata")
+	fileCmd.Flags().Bool("version-from-git", false, "record the git tag or commit of the working directory (git describe --tags --always) as the version of the uploaded build")
+	fileCmd.MarkFlagsMutuallyExclusive("version", "version-from-git")
+
+	bindingErrors := []error{
+		v.BindPFlag("package_name", fileCmd.Flags().Lookup("package")),
+		v.BindPFlag("reuse_existing", fileCmd.Flags().Lookup("reuse-existing")),
+		v.BindPFlag("build_version", fileCmd.Flags().Lookup("version")),
+		v.BindPFlag("version_from_git", fileCmd.Flags().Lookup("version-from-git")),
+	}
+
+	if errs := errors.Join(bindingErrors...); errs != nil {
+		zerolog.Ctx(c).Panic().Err(errs).Msg("Failed binding file level flags")
+	}
+
+	return fileCmd
 }
 
 func ByFile(ctx context.Context, fileName string, config *internal.RunConfig) error {
+	_, err := byFile(ctx, fileName, config)
+	return err
+}
+
+// byFile runs ByFile, returning the assessment it triggered or reused
+func byFile(ctx context.Context, fileName string, config *internal.RunConfig) (*AssessmentResult, error) {
 	log := zerolog.Ctx(ctx)
 	file, err := os.Open(fileName)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer file.Close()
+
+	// The package guard, reuse and app configuration all depend on the package read from the binary
+	required := config.PackageName != "" || config.ReuseExisting || config.AppConfig != ""
+	info, err := inspectBinary(ctx, file, fileName, config.PackageName, required)
+	if err != nil {
+		return nil, err
 	}
 
 	client := config.PlatformClient
 
-	w, err := output.New(config.Output, config.OutputFormat)
+	w, err := output.New(config.Output, config.OutputFormat, config.OutputOptions())
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer w.Close()
 
+	if info != nil {
+		if done, err := applyAppConfig(ctx, config, w, info.Platform, info.Package); done || err != nil {
+			return nil, err
+		}
+	}
+
+	var digest string
+	if config.ReuseExisting {
+		if digest, err = platformapi.FileDigest(file); err != nil {
+			return nil, err
+		}
+
+		existing, err := findExisting(ctx, config, info, digest)
+		if err != nil {
+			return nil, err
+		}
+
+		if existing != nil {
+			return reportExisting(ctx, config, w, info, existing)
+		}
+	}
+
 	buildResponse, err := platformapi.UploadFile(ctx, client, platformapi.UploadFileParams{
-		AnalysisType: config.AnalysisType,
+		AnalysisType:        config.AnalysisType,
+		Group:               config.Group,
+		Version:             config.BuildVersion,
+		HideSensitiveValues: config.HideSensitive,
+		Digest:              digest,
+		File:                file,
+		Attempts:            config.MaxRetries + 1,
+		AttemptTimeout:      config.UploadTimeout,
+		RetryMaxWait:        config.RetryMaxWait,
+		ProgressInterval:    config.UploadProgress,
+	})
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/app/%s/assessment/%s", config.UIHost, buildResponse.Application, buildResponse.Ref)
+	log.Info().Str("URL", url).Msg("Assessment URL")
+	result := &AssessmentResult{Platform: buildResponse.Platform, Package: buildResponse.Package, Task: buildResponse.Task, URL: url}
+
+	if err := saveState(ctx, config, buildResponse.Platform, buildResponse.Package, buildResponse.Task, url); err != nil {
+		return result, err
+	}
+
+	if config.PollForMinutes <= 0 {
+		log.Info().Msg("Succeeded")
+		return result, w.Write(UploadOutput{PostBuild2XX1: buildResponse, Version: config.BuildVersion})
+	}
+
+	taskResponse, err := AwaitAssessment(ctx, config, buildResponse.Platform, buildResponse.Package, buildResponse.Task)
+	if err != nil {
+		return result, err
+	}
+	result.Score = taskResponse.JSON2XX.AdjustedScore
+
+	return result, ReportResults(ctx, config, w, buildResponse.Task, taskResponse)
+}
+
+// inspectBinary fails fast on a file that isn't a mobile binary, or isn't of the expected package, rather than
+// spending an upload and an assessment on it. Unless the inspection is required, a recognized binary whose manifest
+// it can't read is uploaded anyway with a warning and nil is returned, as the platform may accept manifests the
+// inspection doesn't understand
+func inspectBinary(ctx context.Context, file *os.File, fileName, expectedPackage string, required bool) (*appbinary.Info, error) {
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := appbinary.Inspect(file, stat.Size())
+	if err != nil {
+		err = fmt.Errorf("%s is not a supported mobile binary (APK, AAB or IPA): %w", fileName, err)
+		if required || errors.Is(err, appbinary.ErrUnrecognized) {
+			return nil, err
+		}
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("Failed to read the manifest of the binary, uploading it anyway")
+		return nil, nil
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Str("Kind", string(info.Kind)).
+		Str("Platform", info.Platform).
+		Str("Package", info.Package).
+		Str("Version", info.Version).
+		Msg("Inspected binary")
+
+	if expectedPackage != "" && info.Package != expectedPackage {
+		return nil, fmt.Errorf("%s is a build of %s, expected %s", fileName, info.Package, expectedPackage)
+	}
+
+	return info, nil
+}
+
+// findExisting looks for a completed assessment of the same binary, returning nil when one has to be started
+func findExisting(ctx context.Context, config *internal.RunConfig, info *appbinary.Info, digest string) (*platformapi.ExistingAssessment, error) {
+	log := zerolog.Ctx(ctx)
+	existing, err := platformapi.FindCompletedAssessment(ctx, config.PlatformClient, platformapi.FindAssessmentParams{
+		Platform:     info.Platform,
+		PackageName:  info.Package,
 		Group:        config.Group,
-		File:         file,
+		Digest:       digest,
+		AnalysisType: config.AnalysisType,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	log.Info().Str("URL", fmt.Sprintf("%s/app/%s/assessment/%s", config.UIHost, buildResponse.Application, buildResponse.Ref)).Msg("Assessment URL")
+
+	if existing == nil {
+		log.Info().Str("SHA256", digest).Msg("No completed assessment of this binary, uploading it")
+		return nil, nil
+	}
+
+	log.Info().Str("SHA256", digest).Float64("Task", existing.Task).Msg("Reusing completed assessment of this binary")
+	return existing, nil
+}
+
+func reportExisting(ctx context.Context, config *internal.RunConfig, w *output.CLIWriter, info *appbinary.Info, existing *platformapi.ExistingAssessment) (*AssessmentResult, error) {
+	log := zerolog.Ctx(ctx)
+	if config.BuildVersion != "" {
+		log.Warn().Str("Version", config.BuildVersion).Msg("Nothing was uploaded, the version is not recorded")
+		reuseConfig := *config
+		reuseConfig.BuildVersion = ""
+		config = &reuseConfig
+	}
+	url := fmt.Sprintf("%s/app/%s/assessment/%s", config.UIHost, existing.Application, existing.Ref)
+	log.Info().Str("URL", url).Msg("Assessment URL")
+	result := &AssessmentResult{Platform: info.Platform, Package: info.Package, Task: existing.Task, URL: url}
+
+	if err := saveState(ctx, config, info.Platform, info.Package, existing.Task, url); err != nil {
+		return result, err
+	}
+
+	// The assessment is already complete, there is nothing to poll for
+	taskResponse, err := platformapi.GetAssessment(ctx, config.PlatformClient, platformapi.GetAssessmentParams{
+		Platform:    info.Platform,
+		PackageName: info.Package,
+		TaskId:      existing.Task,
+		Group:       config.Group,
+	})
+	if err != nil {
+		return result, err
+	}
+	if taskResponse.JSON2XX == nil {
+		return result, fmt.Errorf("unexpected response for assessment %.0f: %s", existing.Task, taskResponse.Status())
+	}
+	result.Score = taskResponse.JSON2XX.AdjustedScore
 
 	if config.PollForMinutes <= 0 {
 		log.Info().Msg("Succeeded")
-		err = w.Write(buildResponse)
-		return err
+		return result, w.Write(taskResponse.JSON2XX)
+	}
+
+	return result, ReportResults(ctx, config, w, existing.Task, taskResponse)
+}
+
+// UploadOutput is an uploaded build along with the version it was recorded as
+type UploadOutput struct {
+	*platformapi.PostBuild2XX1
+	Version string `json:"version,omitempty"`
+}
+
+// FilesOutput is the combined result of ns run file with several binaries, keyed by platform. Each result embeds
+// the assessment of the binary, while the full output of each is written to its own file
+type FilesOutput struct {
+	Passed    bool                   `json:"passed"`
+	Platforms map[string]BatchResult `json:"platforms"`
+}
+
+// ByFiles uploads and runs assessments for several binaries at once, typically the Android and iOS builds of
+// a release. Results are keyed by platform, and a failure of one doesn't prevent reporting on the others
+func ByFiles(ctx context.Context, fileNames []string, config *internal.RunConfig) error {
+	// The Android package and iOS bundle ID of an app usually differ, a single guard can't match both
+	if config.PackageName != "" {
+		return fmt.Errorf("cannot set package with more than one file")
 	}
 
-	ticker := time.NewTicker(1 * config.PollingInterval)
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(config.PollForMinutes)*config.PollingInterval)
-	defer cancel()
-	taskResponse, err := pollForResults(ctx, client, ticker, config.Group, buildResponse.Package, buildResponse.Platform, buildResponse.Task)
+	w, err := aggregateWriter(config)
 	if err != nil {
 		return err
 	}
+	defer w.Close()
 
-	if config.FindingsArtifactPath != "" {
-		err := writeFindings(ctx, client, buildResponse.Task, config.FindingsArtifactPath)
-		if err != nil {
-			zerolog.Ctx(ctx).Error().Err(err).Str("ArtifactPath", config.FindingsArtifactPath).Msg("Failed to write findings artifact")
+	apps := make([]batch.App, len(fileNames))
+	taken := map[string]bool{}
+	for i, fileName := range fileNames {
+		name := platformOf(fileName)
+		if name == "" || taken[name] {
+			name = filepath.Base(fileName)
+		}
+		// Files of the same name in different directories are told apart by their position
+		for n := i + 1; taken[name]; n++ {
+			name = fmt.Sprintf("%s-%d", filepath.Base(fileName), n)
 		}
+		taken[name] = true
+		apps[i] = batch.App{Name: name, File: fileName}
 	}
 
-	if !isAboveMinimum(taskResponse, config.MinimumScore) {
-		log.Debug().Any("Task", taskResponse).Msg("Task")
-		if err := w.Write(taskResponse.JSON2XX); err != nil {
-			return err
-		}
-		return fmt.Errorf("the score %.2f is less than the required minimum %d", *taskResponse.JSON2XX.AdjustedScore, config.MinimumScore)
+	results := runApps(ctx, apps, len(apps), config)
+	aggregate := FilesOutput{Platforms: map[string]BatchResult{}}
+	for _, result := range results {
+		aggregate.Platforms[result.Name] = result
+	}
+	failed := failedResults(results)
+	aggregate.Passed = len(failed) == 0
+
+	if err := w.Write(aggregate); err != nil {
+		return err
+	}
+
+	if len(failed) > 0 {
+		return &BatchError{Failed: failed}
 	}
 
-	log.Info().Msg("Succeeded")
+	zerolog.Ctx(ctx).Info().Int("Files", len(results)).Msg("Succeeded")
+	return nil
+}
+
+// platformOf guesses the platform of a binary from its extension, or returns an empty string
+func platformOf(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".apk", ".aab":
+		return "android"
+	case ".ipa":
+		return "ios"
+	}
 
-	return w.Write(taskResponse.JSON2XX)
+	return ""
 }
@@ -1,24 +1,40 @@
 package run
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/nowsecure/nowsecure-ci/internal/appbinary"
+	"github.com/nowsecure/nowsecure-ci/internal/buildversion"
+	"github.com/nowsecure/nowsecure-ci/internal/gate"
+	"github.com/nowsecure/nowsecure-ci/internal/output"
 	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+	"github.com/nowsecure/nowsecure-ci/internal/redact"
 )
 
 func TestByFile(t *testing.T) {
-	tmpFile, err := os.CreateTemp("", "test.apk")
-	require.NoError(t, err)
-	defer os.Remove(tmpFile.Name())
-	defer tmpFile.Close()
-
 	packageName := "com.example"
+	apk := filepath.Join(t.TempDir(), "app.apk")
+	writeTestAPK(t, apk, packageName, "1.0.0")
+	var err error
 	appId := uuid.New()
 	completedStatus := platformapi.GetAppPlatformPackageAssessmentTask2XXTaskStatus("completed")
 
@@ -30,7 +46,7 @@ func TestByFile(t *testing.T) {
 		useSuccessfulBuild(t, doer, appId, packageName, config.Platform)
 
 		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
-		err = ByFile(ctx, tmpFile.Name(), config)
+		err = ByFile(ctx, apk, config)
 		require.NoError(t, err)
 	})
 
@@ -54,7 +70,7 @@ func TestByFile(t *testing.T) {
 		UseSuccessfulPolling(t, doer, assessmentResponse)
 
 		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
-		err = ByFile(ctx, tmpFile.Name(), config)
+		err = ByFile(ctx, apk, config)
 		require.NoError(t, err)
 	})
 
@@ -78,7 +94,7 @@ func TestByFile(t *testing.T) {
 		UseFlakyPolling(t, doer, assessmentResponse)
 
 		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
-		err = ByFile(ctx, tmpFile.Name(), config)
+		err = ByFile(ctx, apk, config)
 		require.NoError(t, err)
 	})
 
@@ -103,10 +119,714 @@ func TestByFile(t *testing.T) {
 		UseSuccessfulPolling(t, doer, assessmentResponse)
 
 		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
-		err = ByFile(ctx, tmpFile.Name(), config)
+		err = ByFile(ctx, apk, config)
 		require.ErrorContains(t, err, "less than the required minimum")
 	})
 
+	t.Run("SARIF output contains affected findings", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.PollForMinutes = 1
+		config.OutputFormat = output.SARIF
+		config.Output = filepath.Join(t.TempDir(), "results.sarif")
+
+		useSuccessfulBuild(t, doer, appId, packageName, config.Platform)
+		useSuccessfulFindings(t, doer, []platformapi.GetAssessmentTaskFindings_2XX_Item{
+			{
+				Affected:    true,
+				CheckId:     "insecure_storage",
+				Title:       "Insecure Storage",
+				Severity:    "high",
+				Cvss:        platformapi.Ptr(float32(7.5)),
+				Description: platformapi.Ptr("Data is stored insecurely"),
+			},
+			{
+				Affected: false,
+				CheckId:  "unaffected_check",
+				Title:    "Unaffected Check",
+				Severity: "low",
+			},
+		})
+		UseSuccessfulPolling(t, doer, &GetAssessmentResponse{
+			Application:   &appId,
+			Package:       packageName,
+			Platform:      config.Platform,
+			Task:          1234.50,
+			Ref:           appId,
+			TaskStatus:    &completedStatus,
+			AdjustedScore: platformapi.Ptr(float32(85.5)),
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err = ByFile(ctx, apk, config)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(config.Output)
+		require.NoError(t, err)
+
+		var sarif output.SARIFLog
+		require.NoError(t, json.Unmarshal(data, &sarif))
+		require.Len(t, sarif.Runs, 1)
+		require.Len(t, sarif.Runs[0].Results, 1)
+		assert.Equal(t, "2.1.0", sarif.Version)
+		assert.Equal(t, "insecure_storage", sarif.Runs[0].Results[0].RuleID)
+		assert.Equal(t, "error", sarif.Runs[0].Results[0].Level)
+		assert.Equal(t, "7.5", sarif.Runs[0].Tool.Driver.Rules[0].Properties.SecuritySeverity)
+	})
+
+	t.Run("Markdown summary is written to output and appended to the summary file", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.PollForMinutes = 1
+		config.MinimumScore = 90
+		config.OutputFormat = output.Markdown
+		config.Output = filepath.Join(t.TempDir(), "results.md")
+		config.SummaryFile = filepath.Join(t.TempDir(), "summary.md")
+		require.NoError(t, os.WriteFile(config.SummaryFile, []byte("# Build\n"), 0o644))
+
+		useSuccessfulBuild(t, doer, appId, packageName, config.Platform)
+		useSuccessfulFindings(t, doer, []platformapi.GetAssessmentTaskFindings_2XX_Item{
+			{
+				Affected: true,
+				CheckId:  "insecure_storage",
+				Title:    "Insecure | Storage",
+				Severity: "high",
+				Cvss:     platformapi.Ptr(float32(7.5)),
+			},
+			{
+				Affected: true,
+				CheckId:  "debuggable",
+				Title:    "Debuggable",
+				Severity: "critical",
+			},
+			{
+				Affected: false,
+				CheckId:  "unaffected_check",
+				Title:    "Unaffected Check",
+				Severity: "low",
+			},
+		})
+		UseSuccessfulPolling(t, doer, &GetAssessmentResponse{
+			Application:   &appId,
+			Package:       packageName,
+			Platform:      config.Platform,
+			Task:          1234.50,
+			Ref:           appId,
+			TaskStatus:    &completedStatus,
+			AdjustedScore: platformapi.Ptr(float32(85.5)),
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err = ByFile(ctx, apk, config)
+		require.ErrorContains(t, err, "less than the required minimum")
+
+		data, err := os.ReadFile(config.Output)
+		require.NoError(t, err)
+		markdown := string(data)
+		assert.Contains(t, markdown, ":x: Failed")
+		assert.Contains(t, markdown, "**Score:** 85.50 (minimum 90)")
+		assert.Contains(t, markdown, fmt.Sprintf("(https://localhost:8081/app/%s/assessment/%s)", appId, appId))
+		assert.Contains(t, markdown, "### Affected findings (2)")
+		assert.Contains(t, markdown, "| `insecure_storage` | Insecure \\| Storage | 7.5 | - |")
+		assert.NotContains(t, markdown, "unaffected_check")
+		assert.Less(t, strings.Index(markdown, "#### Critical (1)"), strings.Index(markdown, "#### High (1)"))
+
+		summary, err := os.ReadFile(config.SummaryFile)
+		require.NoError(t, err)
+		assert.Equal(t, "# Build\n"+markdown, string(summary))
+	})
+
+	t.Run("Failing policy rule is reported and written to output", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.PollForMinutes = 1
+		config.Output = filepath.Join(t.TempDir(), "results.json")
+		config.Policy = &gate.Policy{Rules: []gate.Rule{
+			{Name: "score above 80", MinimumScore: platformapi.Ptr(float32(80))},
+			{Name: "no MASVS storage findings", Regulations: []string{"MASVS-STORAGE"}},
+			{Categories: []string{"network"}, Max: 1},
+		}}
+		require.NoError(t, config.Policy.Validate())
+
+		useSuccessfulBuild(t, doer, appId, packageName, config.Platform)
+
+		var findings []platformapi.GetAssessmentTaskFindings_2XX_Item
+		require.NoError(t, json.Unmarshal([]byte(`[
+			{"affected": true, "check_id": "storage_check", "title": "Storage Check", "severity": "medium", "category": "network",
+			 "regulations": [{"type": "masvs", "links": [{"id": "MASVS-STORAGE-1"}]}]},
+			{"affected": false, "check_id": "unaffected_storage_check", "title": "Unaffected Storage Check", "severity": "high",
+			 "regulations": [{"type": "masvs", "links": [{"id": "MASVS-STORAGE-1"}]}]}
+		]`), &findings))
+		useSuccessfulFindings(t, doer, findings)
+
+		UseSuccessfulPolling(t, doer, &GetAssessmentResponse{
+			Application:   &appId,
+			Package:       packageName,
+			Platform:      config.Platform,
+			Task:          1234.50,
+			Ref:           appId,
+			TaskStatus:    &completedStatus,
+			AdjustedScore: platformapi.Ptr(float32(85.5)),
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err = ByFile(ctx, apk, config)
+
+		var policyErr *gate.PolicyError
+		require.ErrorAs(t, err, &policyErr)
+		assert.Equal(t, gate.ExitCode, policyErr.ExitCode())
+		assert.Contains(t, err.Error(), "no MASVS storage findings: 1 matching finding(s), at most 0 allowed [storage_check]")
+		assert.NotContains(t, err.Error(), "score above 80")
+		assert.NotContains(t, err.Error(), "rule 3")
+
+		data, err := os.ReadFile(config.Output)
+		require.NoError(t, err)
+
+		var written struct {
+			Package string          `json:"package"`
+			Policy  gate.Evaluation `json:"policy"`
+		}
+		require.NoError(t, json.Unmarshal(data, &written))
+		assert.Equal(t, packageName, written.Package)
+		assert.False(t, written.Policy.Passed)
+		require.Len(t, written.Policy.Rules, 3)
+		assert.True(t, written.Policy.Rules[0].Passed)
+		assert.False(t, written.Policy.Rules[1].Passed)
+		assert.Equal(t, "rule 3", written.Policy.Rules[2].Name)
+		assert.True(t, written.Policy.Rules[2].Passed)
+	})
+
+	t.Run("Failed upload is retried from the start of the file and its digest verified", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.MaxRetries = 2
+
+		binary := filepath.Join(t.TempDir(), "app.apk")
+		contents := writeTestAPK(t, binary, packageName, "1.0.0")
+		sum := sha256.Sum256(contents)
+
+		var uploads [][]byte
+		isUpload := mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodPost && req.URL.Path == "/build"
+		})
+		recordUpload := func(args mock.Arguments) {
+			body, err := io.ReadAll(args.Get(0).(*http.Request).Body)
+			require.NoError(t, err)
+			uploads = append(uploads, body)
+		}
+		doer.On("Do", isUpload).Run(recordUpload).Return(&http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Status:     "503 Service Unavailable",
+			Body:       io.NopCloser(strings.NewReader("unavailable")),
+		}, nil).Once()
+
+		buildBody, err := json.Marshal(&platformapi.PostBuild2XX1{
+			Application: &appId,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appId,
+			Binary:      platformapi.Ptr(hex.EncodeToString(sum[:])),
+		})
+		require.NoError(t, err)
+		doer.On("Do", isUpload).Run(recordUpload).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(buildBody)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		}, nil).Once()
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, ByFile(ctx, binary, config))
+		require.Len(t, uploads, 2)
+		assert.Equal(t, contents, uploads[0])
+		assert.Equal(t, contents, uploads[1])
+	})
+
+	t.Run("Upload progress and completion are logged", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.UploadProgress = time.Nanosecond
+
+		binary := filepath.Join(t.TempDir(), "app.apk")
+		contents := writeTestAPK(t, binary, packageName, strings.Repeat("1", 1<<15))
+
+		buildBody, err := json.Marshal(&platformapi.PostBuild2XX1{
+			Application: &appId,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appId,
+		})
+		require.NoError(t, err)
+		doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodPost && req.URL.Path == "/build"
+		})).Run(func(args mock.Arguments) {
+			_, err := io.Copy(io.Discard, args.Get(0).(*http.Request).Body)
+			require.NoError(t, err)
+		}).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(buildBody)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		}, nil)
+
+		var logs bytes.Buffer
+		ctx := zerolog.New(&logs).WithContext(context.Background())
+		require.NoError(t, ByFile(ctx, binary, config))
+
+		var progress, complete map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &entry))
+			switch entry["message"] {
+			case "Upload progress":
+				progress = entry
+			case "Upload complete":
+				complete = entry
+			}
+		}
+
+		require.NotNil(t, progress)
+		assert.Equal(t, float64(100), progress["Percent"])
+		assert.Equal(t, float64(len(contents)), progress["Size"])
+		assert.Contains(t, progress, "BytesPerSecond")
+
+		require.NotNil(t, complete)
+		assert.Equal(t, float64(len(contents)), complete["Size"])
+		assert.Contains(t, complete, "Duration")
+	})
+
+	t.Run("Upload with a mismatched digest fails", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.MaxRetries = 1
+
+		buildBody, err := json.Marshal(&platformapi.PostBuild2XX1{
+			Application: &appId,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appId,
+			Binary:      platformapi.Ptr("0000"),
+		})
+		require.NoError(t, err)
+		for range config.MaxRetries + 1 {
+			doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+				return req.Method == http.MethodPost && req.URL.Path == "/build"
+			})).Return(&http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(buildBody)),
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+			}, nil).Once()
+			doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+				return req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/assessment/12345/cancel")
+			})).Return(&http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"status":"cancelled"}`)),
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+			}, nil).Once()
+		}
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err = ByFile(ctx, apk, config)
+		require.ErrorContains(t, err, "does not match the local SHA-256")
+		// The assessment started by each corrupted upload is cancelled
+		doer.AssertNumberOfCalls(t, "Do", 4)
+		for i, call := range doer.Calls {
+			req := call.Arguments.Get(0).(*http.Request)
+			if i%2 == 0 {
+				assert.Equal(t, "/build", req.URL.Path)
+			} else {
+				assert.Equal(t, "/app/android/"+packageName+"/assessment/12345/cancel", req.URL.Path)
+			}
+		}
+	})
+
+	t.Run("Upload with a mismatched digest isn't retried when its assessment can't be cancelled", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.MaxRetries = 1
+
+		buildBody, err := json.Marshal(&platformapi.PostBuild2XX1{
+			Application: &appId,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appId,
+			Binary:      platformapi.Ptr("0000"),
+		})
+		require.NoError(t, err)
+		doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodPost && req.URL.Path == "/build"
+		})).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(buildBody)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		}, nil).Once()
+		doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/assessment/12345/cancel")
+		})).Return(&http.Response{
+			StatusCode: http.StatusForbidden,
+			Body:       io.NopCloser(strings.NewReader(`{"status":"403","name":"Forbidden","message":"no access"}`)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		}, nil).Once()
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err = ByFile(ctx, apk, config)
+		require.ErrorContains(t, err, "does not match the local SHA-256")
+		require.ErrorContains(t, err, "cancelling assessment 12345: HTTP 403 - Forbidden: no access")
+		doer.AssertExpectations(t)
+	})
+
+	t.Run("Completed assessment of an identical binary is reused", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.PollForMinutes = 1
+		config.ReuseExisting = true
+		config.PackageName = packageName
+		config.StateFile = filepath.Join(t.TempDir(), "state.json")
+
+		binary := filepath.Join(t.TempDir(), "app.apk")
+		contents := writeTestAPK(t, binary, packageName, "1.0.0")
+		sum := sha256.Sum256(contents)
+		digest := hex.EncodeToString(sum[:])
+
+		assessments := []GetAssessmentResponse{
+			{
+				Application:   &appId,
+				Binary:        platformapi.Ptr("0000"),
+				Package:       packageName,
+				Platform:      "android",
+				Task:          1,
+				Ref:           uuid.New(),
+				TaskStatus:    &completedStatus,
+				AdjustedScore: platformapi.Ptr(float32(10)),
+			},
+			{
+				Application:   &appId,
+				Binary:        platformapi.Ptr(strings.ToUpper(digest)),
+				Package:       packageName,
+				Platform:      "android",
+				Task:          2,
+				Ref:           appId,
+				TaskStatus:    &completedStatus,
+				AdjustedScore: platformapi.Ptr(float32(85.5)),
+			},
+		}
+		assessments[1].Status.Dynamic = "completed"
+		useAssessmentList(t, doer, assessments)
+		UseSuccessfulPolling(t, doer, &GetAssessmentResponse{
+			Application:   &appId,
+			Package:       packageName,
+			Platform:      "android",
+			Task:          2,
+			Ref:           appId,
+			TaskStatus:    &completedStatus,
+			AdjustedScore: platformapi.Ptr(float32(85.5)),
+		})
+
+		// No upload is mocked, so an unexpected upload fails the test
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, ByFile(ctx, binary, config))
+
+		doer.AssertExpectations(t)
+		for _, call := range doer.Calls {
+			assert.NotContains(t, call.Arguments.Get(0).(*http.Request).URL.Path, "/app/android/com.example/assessment/1")
+		}
+
+		saved, err := os.ReadFile(config.StateFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(saved), `"task": 2`)
+	})
+
+	t.Run("Static-only assessment is not reused for a full analysis", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.AnalysisType = "full"
+		config.ReuseExisting = true
+
+		binary := filepath.Join(t.TempDir(), "app.apk")
+		contents := writeTestAPK(t, binary, packageName, "1.0.0")
+		sum := sha256.Sum256(contents)
+
+		staticOnly := GetAssessmentResponse{
+			Application:   &appId,
+			Binary:        platformapi.Ptr(hex.EncodeToString(sum[:])),
+			Package:       packageName,
+			Platform:      "android",
+			Task:          1,
+			Ref:           appId,
+			TaskStatus:    &completedStatus,
+			AdjustedScore: platformapi.Ptr(float32(95)),
+		}
+		staticOnly.Status.Static = "completed"
+		useAssessmentList(t, doer, []GetAssessmentResponse{staticOnly})
+		useSuccessfulBuild(t, doer, appId, packageName, config.Platform)
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, ByFile(ctx, binary, config))
+		doer.AssertExpectations(t)
+
+		// The same assessment satisfies a static analysis
+		doer = &platformapi.TestRequestDoer{}
+		config = GetTestConfig(t, doer)
+		config.AnalysisType = "static"
+		config.ReuseExisting = true
+		useAssessmentList(t, doer, []GetAssessmentResponse{staticOnly})
+		UseSuccessfulPolling(t, doer, &staticOnly)
+
+		require.NoError(t, ByFile(ctx, binary, config))
+		doer.AssertExpectations(t)
+		for _, call := range doer.Calls {
+			assert.NotEqual(t, "/build", call.Arguments.Get(0).(*http.Request).URL.Path)
+		}
+	})
+
+	t.Run("Unexpected response for a reused assessment is an error", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.ReuseExisting = true
+
+		binary := filepath.Join(t.TempDir(), "app.apk")
+		contents := writeTestAPK(t, binary, packageName, "1.0.0")
+		sum := sha256.Sum256(contents)
+
+		existing := GetAssessmentResponse{
+			Application:   &appId,
+			Binary:        platformapi.Ptr(hex.EncodeToString(sum[:])),
+			Package:       packageName,
+			Platform:      "android",
+			Task:          1,
+			Ref:           appId,
+			TaskStatus:    &completedStatus,
+			AdjustedScore: platformapi.Ptr(float32(95)),
+		}
+		existing.Status.Static = "completed"
+		existing.Status.Dynamic = "completed"
+		useAssessmentList(t, doer, []GetAssessmentResponse{existing})
+		doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/assessment/1")
+		})).Return(&http.Response{
+			Status:     "200 OK",
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("<html>Maintenance</html>")),
+			Header:     http.Header{"Content-Type": []string{"text/html"}},
+		}, nil)
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.EqualError(t, ByFile(ctx, binary, config), "unexpected response for assessment 1: 200 OK")
+	})
+
+	t.Run("Binary without a completed assessment is uploaded", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.ReuseExisting = true
+		config.PackageName = packageName
+
+		binary := filepath.Join(t.TempDir(), "app.apk")
+		writeTestAPK(t, binary, packageName, "1.0.0")
+
+		useAssessmentList(t, doer, []GetAssessmentResponse{{
+			Application:   &appId,
+			Binary:        platformapi.Ptr("0000"),
+			Package:       packageName,
+			Platform:      "android",
+			Task:          1,
+			Ref:           appId,
+			TaskStatus:    &completedStatus,
+			AdjustedScore: platformapi.Ptr(float32(10)),
+		}})
+		useSuccessfulBuild(t, doer, appId, packageName, config.Platform)
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, ByFile(ctx, binary, config))
+		doer.AssertExpectations(t)
+	})
+
+	t.Run("Build version is set on upload and echoed in the output", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.BuildVersion = "1.2.3-nightly.42"
+		config.Output = filepath.Join(t.TempDir(), "output.json")
+
+		buildBody, err := json.Marshal(&platformapi.PostBuild2XX1{
+			Application: &appId,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appId,
+		})
+		require.NoError(t, err)
+		doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodPost && req.URL.Path == "/build" &&
+				req.URL.Query().Get("version") == "1.2.3-nightly.42"
+		})).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(buildBody)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		}, nil)
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, ByFile(ctx, apk, config))
+
+		data, err := os.ReadFile(config.Output)
+		require.NoError(t, err)
+		var written map[string]any
+		require.NoError(t, json.Unmarshal(data, &written))
+		assert.Equal(t, "1.2.3-nightly.42", written["version"])
+		assert.Equal(t, float64(12345), written["task"])
+	})
+
+	t.Run("Sensitive values are hidden by the platform and redacted from the output", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.HideSensitive = true
+		config.Output = filepath.Join(t.TempDir(), "output.json")
+		config.Redactor, err = redact.New(true, nil, nil)
+		require.NoError(t, err)
+
+		build := &platformapi.PostBuild2XX1{
+			Application: &appId,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appId,
+		}
+		build.Config.Dynamic = map[string]any{
+			"login": map[string]any{"username": "ci-user", "password": "hunter2"},
+			"environment": []map[string]any{
+				{"key": "API_TOKEN", "value": "abc123"},
+				{"key": "REGION", "value": "us-east-1"},
+			},
+		}
+		buildBody, err := json.Marshal(build)
+		require.NoError(t, err)
+		doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodPost && req.URL.Path == "/build" &&
+				req.URL.Query().Get("hideSensitiveDataValues") == "true"
+		})).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(buildBody)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		}, nil)
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, ByFile(ctx, apk, config))
+
+		data, err := os.ReadFile(config.Output)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "hunter2")
+		assert.NotContains(t, string(data), "abc123")
+		assert.Contains(t, string(data), "ci-user")
+		assert.Contains(t, string(data), "us-east-1")
+		assert.Contains(t, string(data), `"password":"[REDACTED]"`)
+		assert.Contains(t, string(data), `"task":12345`)
+	})
+
+	t.Run("Build version is derived from git", func(t *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git is not installed")
+		}
+
+		dir := t.TempDir()
+		git := func(args ...string) {
+			cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+			cmd.Dir = dir
+			out, err := cmd.CombinedOutput()
+			require.NoError(t, err, string(out))
+		}
+		git("init", "-q")
+		git("commit", "-q", "--allow-empty", "-m", "initial")
+
+		untagged, err := buildversion.FromGit(context.Background(), dir)
+		require.NoError(t, err)
+		assert.Regexp(t, "^[0-9a-f]{7,}$", untagged)
+
+		git("tag", "v2.0.0")
+		tagged, err := buildversion.FromGit(context.Background(), dir)
+		require.NoError(t, err)
+		assert.Equal(t, "v2.0.0", tagged)
+
+		git("commit", "-q", "--allow-empty", "-m", "next")
+		described, err := buildversion.FromGit(context.Background(), dir)
+		require.NoError(t, err)
+		assert.Regexp(t, "^v2.0.0-1-g[0-9a-f]{7,}$", described)
+
+		_, err = buildversion.FromGit(context.Background(), t.TempDir())
+		require.ErrorContains(t, err, "cannot derive the version from git")
+	})
+
+	t.Run("Dry run diffs the app configuration of the binary's package without uploading it", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.AppConfig = filepath.Join(t.TempDir(), "app-config.yaml")
+		config.DryRun = true
+		config.Output = filepath.Join(t.TempDir(), "output.json")
+		require.NoError(t, os.WriteFile(config.AppConfig, []byte("static:\n  exclude_libraries: true\n"), 0o600))
+
+		useAppConfig(t, doer, map[string]any{"static": map[string]any{"exclude_libraries": true}})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, ByFile(ctx, apk, config))
+
+		require.Len(t, doer.Calls, 1)
+		assert.Equal(t, "/app/android/"+packageName+"/config", doer.Calls[0].Arguments.Get(0).(*http.Request).URL.Path)
+
+		data, err := os.ReadFile(config.Output)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"platform": "android", "package": "`+packageName+`", "changes": [], "applied": false}`, string(data))
+	})
+
+	t.Run("File that isn't a mobile binary is rejected before upload", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+
+		notBinary := filepath.Join(t.TempDir(), "app.apk")
+		require.NoError(t, os.WriteFile(notBinary, []byte("<html>Not found</html>"), 0o644))
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err := ByFile(ctx, notBinary, config)
+		require.ErrorContains(t, err, "not a supported mobile binary")
+		require.ErrorContains(t, err, "not a zip archive")
+		doer.AssertNotCalled(t, "Do", mock.Anything)
+	})
+
+	t.Run("Zip archive without an app manifest is rejected before upload", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+
+		archive := filepath.Join(t.TempDir(), "app.apk")
+		writeTestZip(t, archive, map[string][]byte{"classes.dex": []byte("dex\n035")})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err := ByFile(ctx, archive, config)
+		require.ErrorContains(t, err, "neither an AndroidManifest.xml nor a Payload/*.app/Info.plist")
+		doer.AssertNotCalled(t, "Do", mock.Anything)
+	})
+
+	t.Run("Binary with an unreadable manifest is uploaded when its package isn't needed", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+
+		uninspectable := filepath.Join(t.TempDir(), "app.apk")
+		writeTestZip(t, uninspectable, map[string][]byte{"AndroidManifest.xml": []byte("<manifest/>")})
+		useSuccessfulBuild(t, doer, appId, packageName, config.Platform)
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, ByFile(ctx, uninspectable, config))
+		doer.AssertExpectations(t)
+	})
+
+	t.Run("Binary of another package is rejected before upload", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.PackageName = "com.example.other"
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err := ByFile(ctx, apk, config)
+		require.ErrorContains(t, err, "is a build of com.example, expected com.example.other")
+		doer.AssertNotCalled(t, "Do", mock.Anything)
+	})
+
 	t.Run("Assessment against missing file throws an error", func(t *testing.T) {
 		doer := &platformapi.TestRequestDoer{}
 		config := GetTestConfig(t, doer)
@@ -115,3 +835,62 @@ func TestByFile(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestInspectBinary(t *testing.T) {
+	dir := t.TempDir()
+	ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+
+	tests := []struct {
+		name  string
+		write func(t *testing.T, path, packageName, version string) []byte
+		want  appbinary.Info
+	}{
+		{"app.apk", writeTestAPK, appbinary.Info{Kind: appbinary.APK, Platform: "android", Package: "com.example.apk", Version: "1.2.3"}},
+		{"app.aab", writeTestAAB, appbinary.Info{Kind: appbinary.AAB, Platform: "android", Package: "com.example.aab", Version: "2.0"}},
+		// The file name doesn't decide the kind of binary
+		{"app.bin", writeTestIPA, appbinary.Info{Kind: appbinary.IPA, Platform: "ios", Package: "com.example.ipa", Version: "3.1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			tt.write(t, path, tt.want.Package, tt.want.Version)
+
+			file, err := os.Open(path)
+			require.NoError(t, err)
+			defer file.Close()
+
+			info, err := inspectBinary(ctx, file, path, "", true)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *info)
+		})
+	}
+
+	t.Run("Zip archive without an app manifest is rejected", func(t *testing.T) {
+		path := filepath.Join(dir, "sources.zip")
+		writeTestZip(t, path, map[string][]byte{"main.go": []byte("package main")})
+
+		file, err := os.Open(path)
+		require.NoError(t, err)
+		defer file.Close()
+
+		_, err = inspectBinary(ctx, file, path, "", false)
+		require.ErrorContains(t, err, "neither an AndroidManifest.xml nor a Payload/*.app/Info.plist")
+	})
+
+	t.Run("Unreadable manifest is only an error when the inspection is required", func(t *testing.T) {
+		path := filepath.Join(dir, "malformed.apk")
+		writeTestZip(t, path, map[string][]byte{"AndroidManifest.xml": []byte("<manifest/>")})
+
+		file, err := os.Open(path)
+		require.NoError(t, err)
+		defer file.Close()
+
+		_, err = inspectBinary(ctx, file, path, "", true)
+		require.ErrorContains(t, err, "invalid AndroidManifest.xml")
+
+		info, err := inspectBinary(ctx, file, path, "", false)
+		require.NoError(t, err)
+		assert.Nil(t, info)
+	})
+}
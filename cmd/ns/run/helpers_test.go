@@ -1,13 +1,18 @@
 package run
 
 import (
+	"archive/zip"
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf16"
 
 	"github.com/google/uuid"
 	types "github.com/oapi-codegen/runtime/types"
@@ -193,3 +198,199 @@ func useSuccessfulTriggerAssessment(t *testing.T, doer *platformapi.TestRequestD
 		Header:     http.Header{"Content-Type": []string{"application/json"}},
 	}, nil)
 }
+
+func useSuccessfulCancel(t *testing.T, doer *platformapi.TestRequestDoer) {
+	cancelBody, err := json.Marshal(map[string]string{"status": "cancelled"})
+	require.NoError(t, err)
+	doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/cancel")
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(cancelBody)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}, nil).Once()
+}
+
+func useSuccessfulReport(doer *platformapi.TestRequestDoer, format string, body string) {
+	doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/report."+format)
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}, nil)
+}
+
+func useSuccessfulFindings(t *testing.T, doer *platformapi.TestRequestDoer, findings []platformapi.GetAssessmentTaskFindings_2XX_Item) {
+	findingsBody, err := json.Marshal(findings)
+	require.NoError(t, err)
+	doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/findings")
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(findingsBody)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}, nil)
+}
+
+func useAssessmentList(t *testing.T, doer *platformapi.TestRequestDoer, assessments []GetAssessmentResponse) {
+	listBody, err := json.Marshal(assessments)
+	require.NoError(t, err)
+	doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/assessment")
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(listBody)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}, nil).Once()
+}
+
+func useAppConfig(t *testing.T, doer *platformapi.TestRequestDoer, config map[string]any) {
+	configBody, err := json.Marshal(config)
+	require.NoError(t, err)
+	doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/config")
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(configBody)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}, nil)
+}
+
+// useSetAppConfig records the path of configuration updates
+func useSetAppConfig(doer *platformapi.TestRequestDoer, paths *[]string) {
+	doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/config") {
+			return false
+		}
+		*paths = append(*paths, req.URL.Path)
+		return true
+	})).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader("{}")),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}, nil)
+}
+
+// writeTestAPK writes a minimal APK whose AndroidManifest.xml declares the package and version, returning its contents
+func writeTestAPK(t *testing.T, path, packageName, versionName string) []byte {
+	le := binary.LittleEndian
+	strs := []string{"manifest", "package", "versionName", packageName, versionName}
+
+	var offsets, data []byte
+	for _, s := range strs {
+		offsets = le.AppendUint32(offsets, uint32(len(data)))
+		units := utf16.Encode([]rune(s))
+		data = le.AppendUint16(data, uint16(len(units)))
+		for _, u := range units {
+			data = le.AppendUint16(data, u)
+		}
+		data = le.AppendUint16(data, 0)
+	}
+	for len(data)%4 != 0 {
+		data = append(data, 0)
+	}
+
+	pool := le.AppendUint16(nil, 0x0001)
+	pool = le.AppendUint16(pool, 28)
+	pool = le.AppendUint32(pool, uint32(28+len(offsets)+len(data)))
+	pool = le.AppendUint32(pool, uint32(len(strs)))
+	pool = le.AppendUint32(pool, 0)
+	pool = le.AppendUint32(pool, 0)
+	pool = le.AppendUint32(pool, uint32(28+len(offsets)))
+	pool = le.AppendUint32(pool, 0)
+	pool = append(append(pool, offsets...), data...)
+
+	element := le.AppendUint16(nil, 0x0102)
+	element = le.AppendUint16(element, 16)
+	element = le.AppendUint32(element, 16+20+2*20)
+	element = le.AppendUint32(element, 1)
+	element = le.AppendUint32(element, 0xffffffff)
+	element = le.AppendUint32(element, 0xffffffff)
+	element = le.AppendUint32(element, 0)
+	element = le.AppendUint16(element, 20)
+	element = le.AppendUint16(element, 20)
+	element = le.AppendUint16(element, 2)
+	element = le.AppendUint16(element, 0)
+	element = le.AppendUint16(element, 0)
+	element = le.AppendUint16(element, 0)
+	for _, attr := range [][2]uint32{{1, 3}, {2, 4}} {
+		element = le.AppendUint32(element, 0xffffffff)
+		element = le.AppendUint32(element, attr[0])
+		element = le.AppendUint32(element, attr[1])
+		element = le.AppendUint16(element, 8)
+		element = append(element, 0, 0x03)
+		element = le.AppendUint32(element, attr[1])
+	}
+
+	manifest := le.AppendUint16(nil, 0x0003)
+	manifest = le.AppendUint16(manifest, 8)
+	manifest = le.AppendUint32(manifest, uint32(8+len(pool)+len(element)))
+	manifest = append(append(manifest, pool...), element...)
+
+	return writeTestZip(t, path, map[string][]byte{
+		"AndroidManifest.xml": manifest,
+		"classes.dex":         []byte("dex\n035\x00"),
+	})
+}
+
+// writeTestIPA writes a minimal IPA whose Info.plist declares the bundle ID and version, returning its contents
+func writeTestIPA(t *testing.T, path, bundleID, version string) []byte {
+	plist := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleIcons</key>
+	<dict>
+		<key>CFBundleIdentifier</key>
+		<string>nested</string>
+	</dict>
+	<key>CFBundleIdentifier</key>
+	<string>%s</string>
+	<key>CFBundleShortVersionString</key>
+	<string>%s</string>
+	<key>LSRequiresIPhoneOS</key>
+	<true/>
+</dict>
+</plist>`, bundleID, version)
+
+	return writeTestZip(t, path, map[string][]byte{
+		"Payload/Example.app/Info.plist":                          []byte(plist),
+		"Payload/Example.app/Frameworks/Lib.framework/Info.plist": []byte("<plist/>"),
+	})
+}
+
+func writeTestZip(t *testing.T, path string, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, contents := range files {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write(contents)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+
+	return buf.Bytes()
+}
+
+// writeTestAAB writes a minimal app bundle whose protobuf manifest declares the package and version, returning its contents
+func writeTestAAB(t *testing.T, path, packageName, versionName string) []byte {
+	field := func(b []byte, number uint64, value []byte) []byte {
+		b = binary.AppendUvarint(b, number<<3|2)
+		b = binary.AppendUvarint(b, uint64(len(value)))
+		return append(b, value...)
+	}
+	attribute := func(name, value string) []byte {
+		return field(field(nil, 2, []byte(name)), 3, []byte(value))
+	}
+
+	element := field(nil, 3, []byte("manifest"))
+	element = field(element, 4, attribute("package", packageName))
+	element = field(element, 4, attribute("versionName", versionName))
+
+	return writeTestZip(t, path, map[string][]byte{
+		"base/manifest/AndroidManifest.xml": field(nil, 1, element),
+		"BundleConfig.pb":                   {},
+	})
+}
@@ -3,7 +3,6 @@ package run
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -42,6 +41,13 @@ ns run id [app-id] \
   --minimum-score 70 \
   --poll-for-minutes 60 \
   --group-ref YOUR_GROUP_UUID
+
+# Assess the version currently published in the app store
+ns run id [app-id] \
+  --appstore \
+  --minimum-score 70 \
+  --poll-for-minutes 60 \
+  --group-ref YOUR_GROUP_UUID
 `,
 		ValidArgs: []string{"appId"},
 		Args:      cobra.MinimumNArgs(1),
@@ -50,26 +56,38 @@ ns run id [app-id] \
 			if err != nil {
 				return err
 			}
+			if err := bindAppstoreFlag(v, cmd); err != nil {
+				return err
+			}
 			config, err := internal.NewRunConfig(v)
 			if err != nil {
 				return err
 			}
-			ctx := internal.LoggerWithLevel(config.LogLevel).
+			ctx := internal.LoggerWithLevel(config.LogLevel, config.Redactor).
 				WithContext(cmd.Context())
 
 			return ByID(ctx, appID, config)
 		},
 	}
+
+	idCmd.Flags().Bool("appstore", false, "assess the latest app store version available to NowSecure instead of the latest uploaded binary")
+
 	return idCmd
 }
 
 func ByID(ctx context.Context, appID uuid.UUID, config *internal.RunConfig) error {
+	_, err := byID(ctx, appID, config)
+	return err
+}
+
+// byID runs ByID, returning the assessment it triggered
+func byID(ctx context.Context, appID uuid.UUID, config *internal.RunConfig) (*AssessmentResult, error) {
 	log := zerolog.Ctx(ctx)
 	client := config.PlatformClient
 
-	w, err := output.New(config.Output, config.OutputFormat)
+	w, err := output.New(config.Output, config.OutputFormat, config.OutputOptions())
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer w.Close()
 
@@ -80,54 +98,49 @@ func ByID(ctx context.Context, appID uuid.UUID, config *internal.RunConfig) erro
 		Ref:      &appID,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(appList) != 1 {
-		return fmt.Errorf("got %d elements but expected exactly one", len(appList))
+		return nil, fmt.Errorf("got %d elements but expected exactly one", len(appList))
 	}
 
 	app := appList[0]
 	config.Platform = string(app.Platform)
 
+	if done, err := applyAppConfig(ctx, config, w, config.Platform, app.Package); done || err != nil {
+		return nil, err
+	}
+
 	response, err := platformapi.TriggerAssessment(ctx, client, platformapi.TriggerAssessmentParams{
-		PackageName:  app.Package,
-		Group:        config.Group,
-		AnalysisType: config.AnalysisType,
-		Platform:     string(app.Platform),
+		PackageName:         app.Package,
+		Group:               config.Group,
+		AnalysisType:        config.AnalysisType,
+		Platform:            string(app.Platform),
+		Appstore:            config.Appstore,
+		Failfast:            config.Failfast,
+		HideSensitiveValues: config.HideSensitive,
 	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/app/%s/assessment/%s", config.UIHost, response.JSON2XX.Application, response.JSON2XX.Ref)
+	log.Info().Str("URL", url).Msg("Assessment URL")
+	result := &AssessmentResult{Platform: response.JSON2XX.Platform, Package: response.JSON2XX.Package, Task: float64(response.JSON2XX.Task), URL: url}
+
+	if err := saveState(ctx, config, response.JSON2XX.Platform, response.JSON2XX.Package, float64(response.JSON2XX.Task), url); err != nil {
+		return result, err
 	}
-	log.Info().Str("URL", fmt.Sprintf("%s/app/%s/assessment/%s", config.UIHost, response.JSON2XX.Application, response.JSON2XX.Ref)).Msg("Assessment URL")
 
 	if config.PollForMinutes <= 0 {
 		log.Info().Msg("Succeeded")
-		return w.Write(response.JSON2XX)
+		return result, w.Write(response.JSON2XX)
 	}
 
-	ticker := time.NewTicker(1 * config.PollingInterval)
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(config.PollForMinutes)*config.PollingInterval)
-	defer cancel()
-	taskResponse, err := pollForResults(ctx, client, ticker, config.Group, response.JSON2XX.Package, response.JSON2XX.Platform, float64(response.JSON2XX.Task))
+	taskResponse, err := AwaitAssessment(ctx, config, response.JSON2XX.Platform, response.JSON2XX.Package, float64(response.JSON2XX.Task))
 	if err != nil {
-		return err
-	}
-
-	if config.FindingsArtifactPath != "" {
-		err := writeFindings(ctx, client, float64(response.JSON2XX.Task), config.FindingsArtifactPath)
-		if err != nil {
-			zerolog.Ctx(ctx).Error().Err(err).Str("ArtifactPath", config.FindingsArtifactPath).Msg("Failed to write findings artifact")
-		}
-	}
-
-	if !isAboveMinimum(taskResponse, config.MinimumScore) {
-		log.Debug().Any("Task", taskResponse).Msg("Task")
-		if err := w.Write(taskResponse.JSON2XX); err != nil {
-			return err
-		}
-		return fmt.Errorf("the score %.2f is less than the required minimum %d", *taskResponse.JSON2XX.AdjustedScore, config.MinimumScore)
+		return result, err
 	}
+	result.Score = taskResponse.JSON2XX.AdjustedScore
 
-	log.Info().Msg("Succeeded")
-	return w.Write(taskResponse.JSON2XX)
+	return result, ReportResults(ctx, config, w, float64(response.JSON2XX.Task), taskResponse)
 }
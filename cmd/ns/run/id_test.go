@@ -2,14 +2,22 @@ package run
 
 import (
 	"context"
+	"encoding/json"
+	"encoding/xml"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/nowsecure/nowsecure-ci/internal"
+	"github.com/nowsecure/nowsecure-ci/internal/gate"
+	"github.com/nowsecure/nowsecure-ci/internal/output"
 	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+	"github.com/nowsecure/nowsecure-ci/internal/suppression"
 )
 
 func TestByID(t *testing.T) {
@@ -136,4 +144,191 @@ func TestByID(t *testing.T) {
 		err := ByID(ctx, appID, config)
 		require.ErrorContains(t, err, "less than the required minimum")
 	})
+
+	t.Run("JUnit output reports failing findings and gate", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.PollForMinutes = 1
+		config.MinimumScore = 70
+		config.OutputFormat = output.JUnit
+		config.Output = filepath.Join(t.TempDir(), "results.xml")
+
+		useSuccessfulAppList(t, doer, []platformapi.LabApp{
+			{Package: packageName, Platform: "android"},
+		})
+
+		useSuccessfulTriggerAssessment(t, doer, &TriggerAssessmentResponse{
+			Application: appID,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appID,
+		})
+
+		useSuccessfulFindings(t, doer, []platformapi.GetAssessmentTaskFindings_2XX_Item{
+			{Affected: true, CheckId: "weak_crypto", Title: "Weak Crypto", Severity: "critical", Category: platformapi.Ptr("crypto")},
+			{Affected: false, CheckId: "strong_crypto", Title: "Strong Crypto", Severity: "info", Category: platformapi.Ptr("crypto")},
+			{Affected: false, CheckId: "dynamic_check", Title: "Dynamic Check", Severity: "low", AnalysisType: "dynamic"},
+		})
+
+		UseSuccessfulPolling(t, doer, &GetAssessmentResponse{
+			Application:   &appID,
+			Package:       packageName,
+			Platform:      config.Platform,
+			Task:          12345,
+			Ref:           appID,
+			TaskStatus:    &completedStatus,
+			AdjustedScore: platformapi.Ptr(float32(5.5)),
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err := ByID(ctx, appID, config)
+		require.ErrorContains(t, err, "less than the required minimum")
+
+		data, err := os.ReadFile(config.Output)
+		require.NoError(t, err)
+
+		var suites output.JUnitTestSuites
+		require.NoError(t, xml.Unmarshal(data, &suites))
+		assert.Equal(t, 4, suites.Tests)
+		assert.Equal(t, 2, suites.Failures)
+		require.Len(t, suites.Suites, 3)
+		assert.Equal(t, "gate", suites.Suites[0].Name)
+		assert.Equal(t, "crypto", suites.Suites[1].Name)
+		assert.Equal(t, 1, suites.Suites[1].Failures)
+		assert.Equal(t, "dynamic", suites.Suites[2].Name)
+	})
+
+	t.Run("Suppressed findings are excluded from gating and marked in outputs", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		ignoreFile := filepath.Join(tmpDir, ".ns-ci-ignore.yaml")
+		require.NoError(t, os.WriteFile(ignoreFile, []byte(`suppressions:
+  - check_id: waived_check
+    platform: android
+    justification: Accepted risk, see SEC-123
+    expires: 2999-12-31
+  - check_id: expired_check
+    justification: Fixed in the next release
+    expires: 2020-01-01
+  - check_id: other_package_check
+    package: com.example.other
+    justification: Only applies to another app
+    expires: 2999-12-31
+`), 0o600))
+
+		suppressions, err := suppression.Load(ignoreFile)
+		require.NoError(t, err)
+
+		findings := []platformapi.GetAssessmentTaskFindings_2XX_Item{
+			{Affected: true, CheckId: "waived_check", Title: "Waived Check", Severity: "critical"},
+			{Affected: true, CheckId: "expired_check", Title: "Expired Check", Severity: "low"},
+			{Affected: true, CheckId: "other_package_check", Title: "Other Package Check", Severity: "low"},
+		}
+
+		run := func(t *testing.T, failOnExpired bool) (*internal.RunConfig, error) {
+			doer := &platformapi.TestRequestDoer{}
+			config := GetTestConfig(t, doer)
+			config.PollForMinutes = 1
+			config.SeverityGate = gate.SeverityGate{FailOn: gate.High}
+			config.Suppressions = suppressions
+			config.FailOnExpired = failOnExpired
+			config.FindingsArtifactPath = filepath.Join(t.TempDir(), "findings.json")
+			config.SARIFArtifactPath = filepath.Join(t.TempDir(), "findings.sarif")
+
+			useSuccessfulAppList(t, doer, []platformapi.LabApp{
+				{Package: packageName, Platform: "android"},
+			})
+			useSuccessfulTriggerAssessment(t, doer, &TriggerAssessmentResponse{
+				Application: appID,
+				Package:     packageName,
+				Platform:    config.Platform,
+				Task:        12345,
+				Ref:         appID,
+			})
+			useSuccessfulFindings(t, doer, findings)
+			UseSuccessfulPolling(t, doer, &GetAssessmentResponse{
+				Application:   &appID,
+				Package:       packageName,
+				Platform:      config.Platform,
+				Task:          12345,
+				Ref:           appID,
+				TaskStatus:    &completedStatus,
+				AdjustedScore: platformapi.Ptr(float32(92.5)),
+			})
+
+			ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+			return config, ByID(ctx, appID, config)
+		}
+
+		config, err := run(t, false)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(config.FindingsArtifactPath)
+		require.NoError(t, err)
+		var written []map[string]any
+		require.NoError(t, json.Unmarshal(data, &written))
+		require.Len(t, written, 3)
+		assert.Contains(t, written[0], "suppression")
+		assert.NotContains(t, written[1], "suppression")
+		assert.NotContains(t, written[2], "suppression")
+
+		data, err = os.ReadFile(config.SARIFArtifactPath)
+		require.NoError(t, err)
+		var sarif output.SARIFLog
+		require.NoError(t, json.Unmarshal(data, &sarif))
+		require.Len(t, sarif.Runs[0].Results[0].Suppressions, 1)
+		assert.Contains(t, sarif.Runs[0].Results[0].Suppressions[0].Justification, "SEC-123")
+		assert.Empty(t, sarif.Runs[0].Results[1].Suppressions)
+
+		_, err = run(t, true)
+		var expiredErr *suppression.ExpiredError
+		require.ErrorAs(t, err, &expiredErr)
+		require.Len(t, expiredErr.Expired, 1)
+		assert.Equal(t, "expired_check", expiredErr.Expired[0].CheckID)
+	})
+
+	t.Run("Reports are saved to the artifacts directory", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.PollForMinutes = 1
+		config.ArtifactsDir = t.TempDir()
+		config.SaveReports = []platformapi.ReportFormat{platformapi.ReportPDF, platformapi.ReportHTML}
+
+		useSuccessfulAppList(t, doer, []platformapi.LabApp{
+			{
+				Package:  packageName,
+				Platform: "android",
+			},
+		})
+
+		useSuccessfulTriggerAssessment(t, doer, &TriggerAssessmentResponse{
+			Application: appID,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appID,
+		})
+
+		// Registered before polling, which matches any assessment GET
+		useSuccessfulReport(doer, "pdf", "%PDF-1.7")
+		useSuccessfulReport(doer, "html", "<html></html>")
+		UseSuccessfulPolling(t, doer, &GetAssessmentResponse{
+			Application:   &appID,
+			Package:       packageName,
+			Platform:      config.Platform,
+			Task:          12345,
+			Ref:           appID,
+			TaskStatus:    &completedStatus,
+			AdjustedScore: platformapi.Ptr(float32(92.5)),
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, ByID(ctx, appID, config))
+
+		pdf, err := os.ReadFile(filepath.Join(config.ArtifactsDir, "report.pdf"))
+		require.NoError(t, err)
+		assert.Equal(t, "%PDF-1.7", string(pdf))
+		assert.FileExists(t, filepath.Join(config.ArtifactsDir, "report.html"))
+		assert.NoFileExists(t, filepath.Join(config.ArtifactsDir, "report.json"))
+	})
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
@@ -46,12 +45,26 @@ ns run package [package-name] \
   --minimum-score 70 \
   --poll-for-minutes 60 \
   --group-ref YOUR_GROUP_UUID
+
+# Assess the version currently published in the app store
+ns run package [package-name] \
+  --ios \
+  --appstore \
+  --minimum-score 70 \
+  --poll-for-minutes 60 \
+  --group-ref YOUR_GROUP_UUID
 `,
 		ValidArgs: []string{"packageName"},
 		Args:      cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			config, _ := internal.NewRunConfig(v)
-			ctx := internal.LoggerWithLevel(config.LogLevel).
+			if err := bindAppstoreFlag(v, cmd); err != nil {
+				return err
+			}
+			config, err := internal.NewRunConfig(v)
+			if err != nil {
+				return err
+			}
+			ctx := internal.LoggerWithLevel(config.LogLevel, config.Redactor).
 				WithContext(cmd.Context())
 			packageName := args[0]
 			return ByPackage(ctx, packageName, config)
@@ -60,6 +73,7 @@ ns run package [package-name] \
 
 	packageCmd.Flags().Bool("ios", false, "app is for ios platform")
 	packageCmd.Flags().Bool("android", false, "app is for android platform")
+	packageCmd.Flags().Bool("appstore", false, "assess the latest app store version available to NowSecure instead of the latest uploaded binary")
 
 	packageCmd.MarkFlagsOneRequired("ios", "android")
 	packageCmd.MarkFlagsMutuallyExclusive("ios", "android")
@@ -76,56 +90,62 @@ ns run package [package-name] \
 	return packageCmd
 }
 
+// bindAppstoreFlag binds the --appstore flag of ns run package or ns run id. Viper keeps a single flag per key, so
+// it is bound when the command executes rather than when it is built
+func bindAppstoreFlag(v *viper.Viper, cmd *cobra.Command) error {
+	return v.BindPFlag("appstore", cmd.Flags().Lookup("appstore"))
+}
+
 func ByPackage(ctx context.Context, packageName string, config *internal.RunConfig) error {
+	_, err := byPackage(ctx, packageName, config)
+	return err
+}
+
+// byPackage runs ByPackage, returning the assessment it triggered
+func byPackage(ctx context.Context, packageName string, config *internal.RunConfig) (*AssessmentResult, error) {
 	log := zerolog.Ctx(ctx)
-	w, err := output.New(config.Output, config.OutputFormat)
+	w, err := output.New(config.Output, config.OutputFormat, config.OutputOptions())
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer w.Close()
 
+	if done, err := applyAppConfig(ctx, config, w, config.Platform, packageName); done || err != nil {
+		return nil, err
+	}
+
 	client := config.PlatformClient
 
 	response, err := platformapi.TriggerAssessment(ctx, client, platformapi.TriggerAssessmentParams{
-		PackageName:  packageName,
-		Group:        config.Group,
-		AnalysisType: config.AnalysisType,
-		Platform:     config.Platform,
+		PackageName:         packageName,
+		Group:               config.Group,
+		AnalysisType:        config.AnalysisType,
+		Platform:            config.Platform,
+		Appstore:            config.Appstore,
+		Failfast:            config.Failfast,
+		HideSensitiveValues: config.HideSensitive,
 	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/app/%s/assessment/%s", config.UIHost, response.JSON2XX.Application, response.JSON2XX.Ref)
+	log.Info().Str("URL", url).Msg("Assessment URL")
+	result := &AssessmentResult{Platform: response.JSON2XX.Platform, Package: response.JSON2XX.Package, Task: float64(response.JSON2XX.Task), URL: url}
+
+	if err := saveState(ctx, config, response.JSON2XX.Platform, response.JSON2XX.Package, float64(response.JSON2XX.Task), url); err != nil {
+		return result, err
 	}
-	log.Info().Str("URL", fmt.Sprintf("%s/app/%s/assessment/%s", config.UIHost, response.JSON2XX.Application, response.JSON2XX.Ref)).Msg("Assessment URL")
 
 	if config.PollForMinutes <= 0 {
 		log.Info().Msg("Succeeded")
-		return w.Write(response.JSON2XX)
+		return result, w.Write(response.JSON2XX)
 	}
 
-	ticker := time.NewTicker(1 * config.PollingInterval)
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(config.PollForMinutes)*config.PollingInterval)
-	defer cancel()
-	taskResponse, err := pollForResults(ctx, client, ticker, config.Group, response.JSON2XX.Package, response.JSON2XX.Platform, float64(response.JSON2XX.Task))
+	taskResponse, err := AwaitAssessment(ctx, config, response.JSON2XX.Platform, response.JSON2XX.Package, float64(response.JSON2XX.Task))
 	if err != nil {
-		return err
-	}
-
-	if config.FindingsArtifactPath != "" {
-		err := writeFindings(ctx, client, float64(response.JSON2XX.Task), config.FindingsArtifactPath)
-		if err != nil {
-			zerolog.Ctx(ctx).Error().Err(err).Str("ArtifactPath", config.FindingsArtifactPath).Msg("Failed to write findings artifact")
-			return err
-		}
-	}
-
-	if !isAboveMinimum(taskResponse, config.MinimumScore) {
-		log.Debug().Any("Task", taskResponse).Msg("Task")
-		if err := w.Write(taskResponse.JSON2XX); err != nil {
-			return err
-		}
-		return fmt.Errorf("the score %.2f is less than the required minimum %d", *taskResponse.JSON2XX.AdjustedScore, config.MinimumScore)
+		return result, err
 	}
+	result.Score = taskResponse.JSON2XX.AdjustedScore
 
-	log.Info().Msg("Succeeded")
-	return w.Write(taskResponse.JSON2XX)
+	return result, ReportResults(ctx, config, w, float64(response.JSON2XX.Task), taskResponse)
 }
@@ -4,17 +4,29 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/nowsecure/nowsecure-ci/internal/baseline"
+	"github.com/nowsecure/nowsecure-ci/internal/gate"
+	"github.com/nowsecure/nowsecure-ci/internal/output"
 	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+	"github.com/nowsecure/nowsecure-ci/internal/state"
 )
 
 func TestByPackage(t *testing.T) {
@@ -39,6 +51,203 @@ func TestByPackage(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("App store assessment requests the published version", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.Appstore = true
+
+		triggerBody, err := json.Marshal(&TriggerAssessmentResponse{
+			Application: appID,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appID,
+		})
+		require.NoError(t, err)
+		doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/assessment") &&
+				req.URL.Query().Get("appstore_download") == "*"
+		})).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(triggerBody)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		}, nil)
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, ByPackage(ctx, packageName, config))
+		doer.AssertExpectations(t)
+	})
+
+	t.Run("Latest upload is assessed by default", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+
+		triggerBody, err := json.Marshal(&TriggerAssessmentResponse{
+			Application: appID,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appID,
+		})
+		require.NoError(t, err)
+		doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/assessment") &&
+				!req.URL.Query().Has("appstore_download")
+		})).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(triggerBody)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		}, nil)
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, ByPackage(ctx, packageName, config))
+		doer.AssertExpectations(t)
+	})
+
+	t.Run("Failfast and sensitive value hiding are sent with the trigger", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.Failfast = false
+		config.HideSensitive = true
+
+		triggerBody, err := json.Marshal(&TriggerAssessmentResponse{
+			Application: appID,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appID,
+		})
+		require.NoError(t, err)
+		doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/assessment") &&
+				req.URL.Query().Get("failfast") == "false" &&
+				req.URL.Query().Get("hideSensitiveDataValues") == "true"
+		})).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(triggerBody)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		}, nil)
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, ByPackage(ctx, packageName, config))
+		doer.AssertExpectations(t)
+	})
+
+	t.Run("App configuration is applied before the assessment is triggered", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.AppConfig = filepath.Join(t.TempDir(), "app-config.yaml")
+		require.NoError(t, os.WriteFile(config.AppConfig, []byte("dynamic:\n  timeout: 600\n"), 0o600))
+
+		useAppConfig(t, doer, map[string]any{"dynamic": map[string]any{"timeout": 300}})
+		var updated []string
+		useSetAppConfig(doer, &updated)
+		useSuccessfulTriggerAssessment(t, doer, &TriggerAssessmentResponse{
+			Application: appID,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appID,
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, ByPackage(ctx, packageName, config))
+
+		assert.Equal(t, []string{"/app/android/" + packageName + "/config"}, updated)
+		var methods []string
+		for _, call := range doer.Calls {
+			req := call.Arguments.Get(0).(*http.Request)
+			methods = append(methods, req.Method+" "+req.URL.Path)
+		}
+		assert.Equal(t, []string{
+			"GET /app/android/" + packageName + "/config",
+			"POST /app/android/" + packageName + "/config",
+			"POST /app/android/" + packageName + "/assessment",
+		}, methods)
+	})
+
+	t.Run("Dry run writes the app configuration changes without triggering the assessment", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.AppConfig = filepath.Join(t.TempDir(), "app-config.yaml")
+		config.DryRun = true
+		config.Output = filepath.Join(t.TempDir(), "output.json")
+		require.NoError(t, os.WriteFile(config.AppConfig, []byte("dynamic:\n  timeout: 600\n"), 0o600))
+
+		useAppConfig(t, doer, map[string]any{"dynamic": map[string]any{"timeout": 300}})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, ByPackage(ctx, packageName, config))
+		doer.AssertNumberOfCalls(t, "Do", 1)
+
+		data, err := os.ReadFile(config.Output)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"platform": "android",
+			"package": "`+packageName+`",
+			"changes": [{"path": "dynamic.timeout", "action": "change", "old": 300, "new": 600}],
+			"applied": false
+		}`, string(data))
+	})
+
+	t.Run("Triggered assessment is recorded in the state file", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.StateFile = filepath.Join(t.TempDir(), state.DefaultFile)
+
+		useSuccessfulTriggerAssessment(t, doer, &TriggerAssessmentResponse{
+			Application: appID,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appID,
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, ByPackage(ctx, packageName, config))
+
+		s, err := state.Load(config.StateFile)
+		require.NoError(t, err)
+		assert.Equal(t, packageName, s.Package)
+		assert.Equal(t, config.Platform, s.Platform)
+		assert.Equal(t, float64(12345), s.Task)
+		assert.Equal(t, config.Group, s.Group)
+		assert.Contains(t, s.URL, appID.String())
+	})
+
+	t.Run("Polling timeout cancels the assessment with cancel-on-interrupt", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.PollForMinutes = 1
+		config.PollingInterval = 50 * time.Millisecond
+		config.CancelOnInterrupt = true
+
+		// Registered first, the trigger mock matches any assessment POST
+		useSuccessfulCancel(t, doer)
+		useSuccessfulTriggerAssessment(t, doer, &TriggerAssessmentResponse{
+			Application: appID,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appID,
+		})
+
+		pendingStatus := platformapi.GetAppPlatformPackageAssessmentTask2XXTaskStatus("pending")
+		UseSuccessfulPolling(t, doer, &GetAssessmentResponse{
+			Application: &appID,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appID,
+			TaskStatus:  &pendingStatus,
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err := ByPackage(ctx, packageName, config)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		doer.AssertExpectations(t)
+	})
+
 	t.Run("Successful assessment with polling", func(t *testing.T) {
 		doer := &platformapi.TestRequestDoer{}
 		config := GetTestConfig(t, doer)
@@ -152,4 +361,270 @@ func TestByPackage(t *testing.T) {
 		err := ByPackage(ctx, "com.nonexistent.app", config)
 		require.Error(t, err)
 	})
+
+	t.Run("Affected findings above severity threshold fail the gate", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.PollForMinutes = 1
+		config.SeverityGate = gate.SeverityGate{
+			FailOn:      gate.Critical,
+			MaxFindings: map[gate.Severity]int{gate.High: 1},
+		}
+
+		useSuccessfulTriggerAssessment(t, doer, &TriggerAssessmentResponse{
+			Application: appID,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appID,
+		})
+
+		useSuccessfulFindings(t, doer, []platformapi.GetAssessmentTaskFindings_2XX_Item{
+			{Affected: true, CheckId: "critical_check", Title: "Critical Check", Severity: "critical"},
+			{Affected: true, CheckId: "high_check_1", Title: "High Check 1", Severity: "high"},
+			{Affected: true, CheckId: "high_check_2", Title: "High Check 2", Severity: "high"},
+			{Affected: false, CheckId: "unaffected_critical", Title: "Unaffected Critical", Severity: "critical"},
+			{Affected: true, CheckId: "medium_check", Title: "Medium Check", Severity: "medium"},
+		})
+
+		UseSuccessfulPolling(t, doer, &GetAssessmentResponse{
+			Application:   &appID,
+			Package:       packageName,
+			Platform:      config.Platform,
+			Task:          12345,
+			Ref:           appID,
+			TaskStatus:    &completedStatus,
+			AdjustedScore: platformapi.Ptr(float32(95)),
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err := ByPackage(ctx, packageName, config)
+
+		var gateErr *gate.Error
+		require.ErrorAs(t, err, &gateErr)
+		assert.Equal(t, gate.ExitCode, gateErr.ExitCode())
+		require.Len(t, gateErr.Violations, 3)
+		assert.Equal(t, "critical_check", gateErr.Violations[0].Finding.CheckId)
+		assert.Equal(t, "high_check_1", gateErr.Violations[1].Finding.CheckId)
+		assert.Equal(t, "high_check_2", gateErr.Violations[2].Finding.CheckId)
+		assert.Contains(t, err.Error(), "max-findings high=1 (found 2)")
+	})
+
+	t.Run("Affected findings below severity threshold pass the gate", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.PollForMinutes = 1
+		config.SeverityGate = gate.SeverityGate{FailOn: gate.High}
+
+		useSuccessfulTriggerAssessment(t, doer, &TriggerAssessmentResponse{
+			Application: appID,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appID,
+		})
+
+		useSuccessfulFindings(t, doer, []platformapi.GetAssessmentTaskFindings_2XX_Item{
+			{Affected: true, CheckId: "medium_check", Title: "Medium Check", Severity: "medium"},
+			{Affected: false, CheckId: "unaffected_critical", Title: "Unaffected Critical", Severity: "critical"},
+		})
+
+		UseSuccessfulPolling(t, doer, &GetAssessmentResponse{
+			Application:   &appID,
+			Package:       packageName,
+			Platform:      config.Platform,
+			Task:          12345,
+			Ref:           appID,
+			TaskStatus:    &completedStatus,
+			AdjustedScore: platformapi.Ptr(float32(95)),
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err := ByPackage(ctx, packageName, config)
+		require.NoError(t, err)
+	})
+
+	t.Run("Table output lists affected findings by severity", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.PollForMinutes = 1
+		config.MinimumScore = 50
+		config.OutputFormat = output.Table
+		config.Output = filepath.Join(t.TempDir(), "results.txt")
+
+		useSuccessfulTriggerAssessment(t, doer, &TriggerAssessmentResponse{
+			Application: appID,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appID,
+		})
+
+		useSuccessfulFindings(t, doer, []platformapi.GetAssessmentTaskFindings_2XX_Item{
+			{Affected: true, CheckId: "medium_check", Title: "Medium Check", Severity: "medium"},
+			{Affected: true, CheckId: "critical_check", Title: "Critical Check", Severity: "critical", Cvss: platformapi.Ptr(float32(9.8))},
+			{Affected: false, CheckId: "unaffected_high", Title: "Unaffected High", Severity: "high"},
+		})
+
+		UseSuccessfulPolling(t, doer, &GetAssessmentResponse{
+			Application:   &appID,
+			Package:       packageName,
+			Platform:      config.Platform,
+			Task:          12345,
+			Ref:           appID,
+			TaskStatus:    &completedStatus,
+			AdjustedScore: platformapi.Ptr(float32(72.5)),
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		require.NoError(t, ByPackage(ctx, packageName, config))
+
+		data, err := os.ReadFile(config.Output)
+		require.NoError(t, err)
+		table := string(data)
+
+		assert.NotContains(t, table, "\x1b[", "file output is never colored")
+		assert.Contains(t, table, "App:     com.example (android)\n")
+		assert.Contains(t, table, "Task:    12345\n")
+		assert.Contains(t, table, "Score:   72.50 (minimum 50)\n")
+		assert.Contains(t, table, "Result:  PASSED\n")
+		assert.Contains(t, table, "Affected findings: 2\n")
+		assert.Contains(t, table, "SEVERITY  CVSS  CHECK           TITLE\n")
+		assert.Contains(t, table, "critical  9.8   critical_check  Critical Check\n")
+		assert.Contains(t, table, "medium    -     medium_check    Medium Check\n")
+		assert.Less(t, strings.Index(table, "critical_check"), strings.Index(table, "medium_check"))
+		assert.NotContains(t, table, "unaffected_high")
+	})
+
+	t.Run("Table output is colored and fit to the terminal width", func(t *testing.T) {
+		report := &output.Report{
+			Package:      packageName,
+			Platform:     "android",
+			Task:         12345,
+			Score:        40,
+			MinimumScore: 50,
+			GateError:    errors.New("the score 40.00 is less than the required minimum 50"),
+			Findings: []platformapi.GetAssessmentTaskFindings_2XX_Item{
+				{Affected: true, CheckId: "high_check", Title: strings.Repeat("Very long title ", 10), Severity: "high"},
+			},
+		}
+
+		table := output.NewTable(report, 60, true)
+		assert.Contains(t, table, "\x1b[1m\x1b[31mFAILED\x1b[0m")
+		assert.Contains(t, table, "\x1b[31mhigh    \x1b[0m")
+		for _, line := range strings.Split(table, "\n") {
+			if strings.Contains(line, "high_check") {
+				assert.Equal(t, 60, utf8.RuneCountInString(stripANSI(line)))
+				assert.True(t, strings.HasSuffix(line, "…"))
+			}
+		}
+
+		assert.NotContains(t, output.NewTable(report, 60, false), "\x1b[")
+	})
+
+	t.Run("Baselined findings are excluded from gating", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.PollForMinutes = 1
+		config.SeverityGate = gate.SeverityGate{FailOn: gate.High}
+
+		findings := []platformapi.GetAssessmentTaskFindings_2XX_Item{
+			{Affected: true, CheckId: "accepted_check", Title: "Accepted Check", Severity: "critical"},
+			{Affected: true, CheckId: "new_check", Title: "New Check", Severity: "high"},
+		}
+		config.Baseline = baseline.New(findings[:1], 1)
+
+		useSuccessfulTriggerAssessment(t, doer, &TriggerAssessmentResponse{
+			Application: appID,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appID,
+		})
+
+		useSuccessfulFindings(t, doer, findings)
+
+		UseSuccessfulPolling(t, doer, &GetAssessmentResponse{
+			Application:   &appID,
+			Package:       packageName,
+			Platform:      config.Platform,
+			Task:          12345,
+			Ref:           appID,
+			TaskStatus:    &completedStatus,
+			AdjustedScore: platformapi.Ptr(float32(95)),
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err := ByPackage(ctx, packageName, config)
+
+		var gateErr *gate.Error
+		require.ErrorAs(t, err, &gateErr)
+		require.Len(t, gateErr.Violations, 1)
+		assert.Equal(t, "new_check", gateErr.Violations[0].Finding.CheckId)
+	})
+
+	t.Run("JUnit output agrees with the severity gate and baseline", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		config := GetTestConfig(t, doer)
+		config.PollForMinutes = 1
+		config.SeverityGate = gate.SeverityGate{FailOn: gate.High}
+		config.OutputFormat = output.JUnit
+		config.Output = filepath.Join(t.TempDir(), "results.xml")
+
+		findings := []platformapi.GetAssessmentTaskFindings_2XX_Item{
+			{Affected: true, CheckId: "accepted_check", Title: "Accepted Check", Severity: "critical", Category: platformapi.Ptr("crypto")},
+			{Affected: true, CheckId: "new_check", Title: "New Check", Severity: "high", Category: platformapi.Ptr("crypto")},
+		}
+		config.Baseline = baseline.New(findings[:1], 1)
+
+		useSuccessfulTriggerAssessment(t, doer, &TriggerAssessmentResponse{
+			Application: appID,
+			Package:     packageName,
+			Platform:    config.Platform,
+			Task:        12345,
+			Ref:         appID,
+		})
+
+		useSuccessfulFindings(t, doer, findings)
+
+		UseSuccessfulPolling(t, doer, &GetAssessmentResponse{
+			Application:   &appID,
+			Package:       packageName,
+			Platform:      config.Platform,
+			Task:          12345,
+			Ref:           appID,
+			TaskStatus:    &completedStatus,
+			AdjustedScore: platformapi.Ptr(float32(95)),
+		})
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		var gateErr *gate.Error
+		require.ErrorAs(t, ByPackage(ctx, packageName, config), &gateErr)
+
+		data, err := os.ReadFile(config.Output)
+		require.NoError(t, err)
+
+		var suites output.JUnitTestSuites
+		require.NoError(t, xml.Unmarshal(data, &suites))
+		require.Len(t, suites.Suites, 2)
+
+		gateSuite := suites.Suites[0]
+		require.Len(t, gateSuite.TestCases, 2)
+		assert.Nil(t, gateSuite.TestCases[0].Failure)
+		assert.Equal(t, "severity", gateSuite.TestCases[1].Name)
+		require.NotNil(t, gateSuite.TestCases[1].Failure)
+		assert.Contains(t, gateSuite.TestCases[1].Failure.Content, "new_check")
+
+		crypto := suites.Suites[1]
+		assert.Equal(t, 1, crypto.Failures)
+		assert.Equal(t, 1, crypto.Skipped)
+		require.NotNil(t, crypto.TestCases[0].Skipped)
+		assert.Equal(t, "accepted in the baseline", crypto.TestCases[0].Skipped.Message)
+	})
+}
+
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func stripANSI(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
 }
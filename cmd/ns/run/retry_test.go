@@ -0,0 +1,94 @@
+package run
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+func retryingClient(t *testing.T, doer *platformapi.TestRequestDoer) platformapi.ClientWithResponsesInterface {
+	client, err := platformapi.ClientFromConfig(platformapi.Config{
+		Host:         "https://localhost:8080",
+		UserAgent:    "test/1.0",
+		Token:        "token",
+		MaxRetries:   3,
+		RetryMaxWait: 10 * time.Millisecond,
+	}, doer)
+	require.NoError(t, err)
+
+	return client
+}
+
+func statusResponse(statusCode int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Type", "application/json")
+
+	return &http.Response{
+		StatusCode: statusCode,
+		Status:     http.StatusText(statusCode),
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestRetries(t *testing.T) {
+	isFindings := mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/findings")
+	})
+
+	t.Run("Idempotent requests are retried on 429 and 5XX", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		doer.On("Do", isFindings).Return(statusResponse(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"0"}}, "{}"), nil).Once()
+		doer.On("Do", isFindings).Return(statusResponse(http.StatusServiceUnavailable, nil, "{}"), nil).Once()
+		doer.On("Do", isFindings).Return(statusResponse(http.StatusOK, nil, `[{"check_id":"allow_backup","affected":true}]`), nil).Once()
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		findings, err := platformapi.GetFindings(ctx, retryingClient(t, doer), 12345)
+		require.NoError(t, err)
+		require.Len(t, *findings, 1)
+		assert.Equal(t, "allow_backup", (*findings)[0].CheckId)
+		doer.AssertNumberOfCalls(t, "Do", 3)
+	})
+
+	t.Run("Retries give up after the maximum", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		doer.On("Do", isFindings).Return(statusResponse(http.StatusBadGateway, nil, `{"message":"bad gateway"}`), nil)
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		_, err := platformapi.GetFindings(ctx, retryingClient(t, doer), 12345)
+		require.Error(t, err)
+		doer.AssertNumberOfCalls(t, "Do", 4)
+	})
+
+	t.Run("Client errors and non-idempotent requests are not retried", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		doer.On("Do", isFindings).Return(statusResponse(http.StatusNotFound, nil, `{"message":"not found"}`), nil)
+		doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodPost
+		})).Return(statusResponse(http.StatusServiceUnavailable, nil, `{"message":"unavailable"}`), nil)
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		client := retryingClient(t, doer)
+
+		_, err := platformapi.GetFindings(ctx, client, 12345)
+		require.Error(t, err)
+
+		_, err = platformapi.CancelAssessment(ctx, client, platformapi.CancelAssessmentParams{Platform: "android", PackageName: "com.example", TaskId: 12345})
+		require.Error(t, err)
+
+		doer.AssertNumberOfCalls(t, "Do", 2)
+	})
+}
@@ -11,11 +11,16 @@ import (
 	types "github.com/oapi-codegen/runtime/types"
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
 	"github.com/nowsecure/nowsecure-ci/internal"
+	"github.com/nowsecure/nowsecure-ci/internal/appconfig"
+	"github.com/nowsecure/nowsecure-ci/internal/gate"
 	"github.com/nowsecure/nowsecure-ci/internal/output"
 	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+	"github.com/nowsecure/nowsecure-ci/internal/state"
+	"github.com/nowsecure/nowsecure-ci/internal/suppression"
 )
 
 //revive:disable:exported
@@ -25,6 +30,40 @@ func RunCommand(ctx context.Context, v *viper.Viper, config *internal.BaseConfig
 		Short: "Run an assessment for a given application",
 	}
 
+	runCmd.PersistentFlags().String("analysis-type", "full", "One of: full, static, sbom")
+	runCmd.PersistentFlags().Duration("upload-timeout", 30*time.Minute, "time limit of each binary upload attempt, failed attempts are retried up to max-retries times. 0 for no limit")
+	runCmd.PersistentFlags().Duration("upload-progress-interval", 30*time.Second, "how often binary upload progress is logged. 0 to only log the completed upload")
+	runCmd.PersistentFlags().Bool("failfast", true, "run the automation pass of the dynamic analysis first so scripting failures surface quickly. Does not apply to uploaded binaries")
+	runCmd.PersistentFlags().String("app-config", "", "YAML file of app configuration to apply to the app before it is assessed, see ns app config")
+	runCmd.PersistentFlags().Bool("dry-run", false, "list the changes app-config would make to the app configuration without applying them or running the assessment")
+	runCmd.PersistentFlags().String("state-file", state.DefaultFile, "file recording the triggered assessment, resumable with ns assessment wait. Written when poll-for-minutes is 0 unless set explicitly, empty to disable")
+	AddResultFlags(ctx, runCmd.PersistentFlags(), config)
+	bindingErrors := []error{
+		v.BindPFlag("analysis_type", runCmd.PersistentFlags().Lookup("analysis-type")),
+		v.BindPFlag("state_file", runCmd.PersistentFlags().Lookup("state-file")),
+		v.BindPFlag("failfast", runCmd.PersistentFlags().Lookup("failfast")),
+		v.BindPFlag("app_config", runCmd.PersistentFlags().Lookup("app-config")),
+		v.BindPFlag("dry_run", runCmd.PersistentFlags().Lookup("dry-run")),
+		v.BindPFlag("upload_timeout", runCmd.PersistentFlags().Lookup("upload-timeout")),
+		v.BindPFlag("upload_progress_interval", runCmd.PersistentFlags().Lookup("upload-progress-interval")),
+		BindResultFlags(v, runCmd.PersistentFlags()),
+	}
+	if errs := errors.Join(bindingErrors...); errs != nil {
+		zerolog.Ctx(ctx).Panic().Err(errs).Msg("Failed binding run level flags")
+	}
+
+	runCmd.AddCommand(
+		FileCommand(ctx, v, config),
+		IDCommand(v, config),
+		PackageCommand(ctx, v, config),
+		BatchCommand(ctx, v, config),
+	)
+
+	return runCmd
+}
+
+// AddResultFlags registers the flags controlling how an assessment is polled for, gated and saved
+func AddResultFlags(ctx context.Context, flags *pflag.FlagSet, config *internal.BaseConfig) {
 	pwd, err := os.Getwd()
 	if err != nil {
 		zerolog.Ctx(ctx).Panic().Err(err).Msg("Failed to get present working directory")
@@ -37,37 +76,119 @@ func RunCommand(ctx context.Context, v *viper.Viper, config *internal.BaseConfig
 		dir = pwd
 	}
 
-	runCmd.PersistentFlags().String("analysis-type", "full", "One of: full, static, sbom")
-	runCmd.PersistentFlags().Int("poll-for-minutes", 60, "polling max duration")
-	runCmd.PersistentFlags().Int("minimum-score", 0, "score threshold below which we exit code 1")
-	runCmd.PersistentFlags().String("artifacts-dir", dir, "directory in which to put artifacts")
-	runCmd.PersistentFlags().Bool("save-findings", false, fmt.Sprintf("fetch all findings associated with an assessment and write to %s", filepath.Join(dir, "findings.json")))
-	bindingErrors := []error{
-		v.BindPFlag("save_findings", runCmd.PersistentFlags().Lookup("save-findings")),
-		v.BindPFlag("artifacts_dir", runCmd.PersistentFlags().Lookup("artifacts-dir")),
-		v.BindPFlag("analysis_type", runCmd.PersistentFlags().Lookup("analysis-type")),
-		v.BindPFlag("poll_for_minutes", runCmd.PersistentFlags().Lookup("poll-for-minutes")),
-		v.BindPFlag("minimum_score", runCmd.PersistentFlags().Lookup("minimum-score")),
+	flags.Int("poll-for-minutes", 60, "polling max duration")
+	flags.Int("minimum-score", 0, "score threshold below which we exit code 1")
+	flags.String("fail-on-severity", "", "fail with exit code 2 if any affected finding is at or above this severity. One of: info, warn, low, medium, high, critical")
+	flags.String("max-findings", "", "fail with exit code 2 if affected findings of a severity exceed a count, e.g. critical=0,high=3")
+	flags.String("baseline", "", "baseline file of accepted findings, only findings missing from it are gated on")
+	flags.String("ignore-file", suppression.DefaultFile, "suppression file of waived check IDs, excluded from gating until they expire")
+	flags.Bool("fail-on-expired-suppression", false, "fail with exit code 2 instead of warning when a suppression has expired")
+	flags.Bool("cancel-on-interrupt", false, "cancel the assessment on the platform if the job is interrupted or polling times out")
+	flags.String("artifacts-dir", dir, "directory in which to put artifacts")
+	flags.String("summary-file", "", "append a markdown summary of the assessment to this file, e.g. $GITHUB_STEP_SUMMARY")
+	flags.String("save-report", "", "comma separated assessment reports to download into the artifacts directory. Any of: pdf, html, json")
+	flags.Bool("save-findings", false, fmt.Sprintf("fetch all findings associated with an assessment and write to %s and %s", filepath.Join(dir, "findings.json"), filepath.Join(dir, "findings.sarif")))
+}
+
+// BindResultFlags binds the flags registered by AddResultFlags. Viper keeps a single flag per key, so commands
+// other than run must bind when they execute rather than when they are built
+func BindResultFlags(v *viper.Viper, flags *pflag.FlagSet) error {
+	return errors.Join(
+		v.BindPFlag("save_findings", flags.Lookup("save-findings")),
+		v.BindPFlag("artifacts_dir", flags.Lookup("artifacts-dir")),
+		v.BindPFlag("poll_for_minutes", flags.Lookup("poll-for-minutes")),
+		v.BindPFlag("minimum_score", flags.Lookup("minimum-score")),
+		v.BindPFlag("fail_on_severity", flags.Lookup("fail-on-severity")),
+		v.BindPFlag("max_findings", flags.Lookup("max-findings")),
+		v.BindPFlag("baseline", flags.Lookup("baseline")),
+		v.BindPFlag("ignore_file", flags.Lookup("ignore-file")),
+		v.BindPFlag("fail_on_expired_suppression", flags.Lookup("fail-on-expired-suppression")),
+		v.BindPFlag("cancel_on_interrupt", flags.Lookup("cancel-on-interrupt")),
+		v.BindPFlag("save_report", flags.Lookup("save-report")),
+		v.BindPFlag("summary_file", flags.Lookup("summary-file")),
+	)
+}
+
+// saveState records a triggered assessment so that a later stage can resume polling with ns assessment wait
+func saveState(ctx context.Context, config *internal.RunConfig, platform, packageName string, task float64, url string) error {
+	if config.StateFile == "" {
+		return nil
 	}
-	if errs := errors.Join(bindingErrors...); errs != nil {
-		zerolog.Ctx(ctx).Panic().Err(errs).Msg("Failed binding run level flags")
+
+	if err := state.New(platform, packageName, task, config.Group, url).Save(config.StateFile); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
 	}
+	zerolog.Ctx(ctx).Debug().Str("Path", config.StateFile).Msg("Wrote state file")
 
-	runCmd.AddCommand(
-		FileCommand(v, config),
-		IDCommand(v, config),
-		PackageCommand(ctx, v, config),
-	)
+	return nil
+}
 
-	return runCmd
+// applyAppConfig brings the app configuration in line with the app-config file before the app is assessed. On a
+// dry run the changes are written to the output instead, and it reports that the run should stop there
+func applyAppConfig(ctx context.Context, config *internal.RunConfig, w *output.CLIWriter, platform, packageName string) (bool, error) {
+	if config.AppConfig == "" {
+		return false, nil
+	}
+
+	result, err := appconfig.Sync(ctx, config.PlatformClient, appconfig.SyncParams{
+		App:           appconfig.AppRef{Platform: platform, Package: packageName},
+		Group:         config.Group,
+		Path:          config.AppConfig,
+		DryRun:        config.DryRun,
+		HideSensitive: config.HideSensitive,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if config.DryRun {
+		zerolog.Ctx(ctx).Info().Msg("Dry run, not running the assessment")
+		return true, w.Write(result)
+	}
+
+	return false, nil
 }
 
-func pollForResults(ctx context.Context, client platformapi.ClientWithResponsesInterface, ticker *time.Ticker, group types.UUID, packageName, platform string, task float64) (*platformapi.GetAppPlatformPackageAssessmentTaskResponse, error) {
+// AwaitAssessment polls for a triggered assessment for up to the configured duration. With cancel-on-interrupt set,
+// an assessment still running when the job is interrupted or polling times out is cancelled on the platform
+func AwaitAssessment(ctx context.Context, config *internal.RunConfig, platform, packageName string, task float64) (*platformapi.GetAppPlatformPackageAssessmentTaskResponse, error) {
+	ticker := time.NewTicker(1 * config.PollingInterval)
+	pollCtx, cancel := context.WithTimeout(ctx, time.Duration(config.PollForMinutes)*config.PollingInterval)
+	defer cancel()
+
+	taskResponse, err := PollForResults(pollCtx, config.PlatformClient, ticker, config.Group, packageName, platform, task)
+	if err != nil && config.CancelOnInterrupt && pollCtx.Err() != nil {
+		cancelAssessment(ctx, config, platform, packageName, task)
+	}
+
+	return taskResponse, err
+}
+
+func cancelAssessment(ctx context.Context, config *internal.RunConfig, platform, packageName string, task float64) {
+	log := zerolog.Ctx(ctx)
+	// The job context is already done, give the cancellation its own deadline
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+
+	status, err := platformapi.CancelAssessment(ctx, config.PlatformClient, platformapi.CancelAssessmentParams{
+		Platform:    platform,
+		PackageName: packageName,
+		TaskId:      task,
+	})
+	if err != nil {
+		log.Error().Err(err).Float64("Task", task).Msg("Failed to cancel assessment")
+		return
+	}
+
+	log.Warn().Float64("Task", task).Str("Status", status).Msg("Cancelled assessment")
+}
+
+func PollForResults(ctx context.Context, client platformapi.ClientWithResponsesInterface, ticker *time.Ticker, group types.UUID, packageName, platform string, task float64) (*platformapi.GetAppPlatformPackageAssessmentTaskResponse, error) {
 	zerolog.Ctx(ctx).Debug().Msg("Polling started")
 
 	defer ticker.Stop()
 
-	if resp, shouldContinue, err := checkAssessment(ctx, client, group, packageName, platform, task); !shouldContinue {
+	if resp, shouldContinue, err := CheckAssessment(ctx, client, group, packageName, platform, task); !shouldContinue {
 		return resp, err
 	}
 
@@ -76,14 +197,14 @@ func pollForResults(ctx context.Context, client platformapi.ClientWithResponsesI
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		case <-ticker.C:
-			if resp, shouldContinue, err := checkAssessment(ctx, client, group, packageName, platform, task); !shouldContinue {
+			if resp, shouldContinue, err := CheckAssessment(ctx, client, group, packageName, platform, task); !shouldContinue {
 				return resp, err
 			}
 		}
 	}
 }
 
-func checkAssessment(ctx context.Context, client platformapi.ClientWithResponsesInterface, group types.UUID, packageName, platform string, task float64) (*platformapi.GetAppPlatformPackageAssessmentTaskResponse, bool, error) {
+func CheckAssessment(ctx context.Context, client platformapi.ClientWithResponsesInterface, group types.UUID, packageName, platform string, task float64) (*platformapi.GetAppPlatformPackageAssessmentTaskResponse, bool, error) {
 	resp, err := platformapi.GetAssessment(ctx, client, platformapi.GetAssessmentParams{
 		Platform:    platform,
 		PackageName: packageName,
@@ -127,27 +248,143 @@ func checkAssessment(ctx context.Context, client platformapi.ClientWithResponses
 	return nil, true, nil
 }
 
-func isAboveMinimum(taskResponse *platformapi.GetAppPlatformPackageAssessmentTaskResponse, threshold int) bool {
-	return *taskResponse.JSON2XX.AdjustedScore >= float32(threshold)
-}
+// ReportResults writes the outcome of a completed assessment and applies the configured gates
+func ReportResults(ctx context.Context, config *internal.RunConfig, w *output.CLIWriter, task float64, taskResponse *platformapi.GetAppPlatformPackageAssessmentTaskResponse) error {
+	log := zerolog.Ctx(ctx)
 
-func writeFindings(ctx context.Context, client platformapi.ClientWithResponsesInterface, task float64, artifactPath string) error {
-	findings, err := platformapi.GetFindings(ctx, client, task)
-	if err != nil || findings == nil {
-		return err
+	report := &output.Report{
+		Assessment:   taskResponse.JSON2XX,
+		Package:      taskResponse.JSON2XX.Package,
+		Platform:     taskResponse.JSON2XX.Platform,
+		Task:         task,
+		Score:        *taskResponse.JSON2XX.AdjustedScore,
+		MinimumScore: config.MinimumScore,
+		Version:      config.BuildVersion,
+	}
+	if taskResponse.JSON2XX.Application != nil {
+		report.URL = fmt.Sprintf("%s/app/%s/assessment/%s", config.UIHost, *taskResponse.JSON2XX.Application, taskResponse.JSON2XX.Ref)
+	}
+
+	requiresFindings := config.OutputFormat.RequiresFindings() || config.SeverityGate.Enabled() || config.Policy.Enabled() || config.SummaryFile != ""
+	// Table output lists the findings when they are available but doesn't depend on them
+	if config.FindingsArtifactPath != "" || requiresFindings || config.OutputFormat == output.Table {
+		findings, err := platformapi.GetFindings(ctx, config.PlatformClient, task)
+		if err != nil {
+			if requiresFindings {
+				return err
+			}
+			log.Error().Err(err).Msg("Failed to fetch findings")
+		}
+		if findings != nil {
+			report.Findings = *findings
+		}
+	}
+
+	gateFindings := report.Findings
+	report.Baseline = config.Baseline
+	if config.Baseline != nil && len(report.Findings) > 0 {
+		gateFindings = config.Baseline.NewFindings(report.Findings)
+		log.Info().Int("Baselined", len(report.Findings)-len(gateFindings)).Msg("Excluded baselined findings from gating")
 	}
 
-	var filteredFindings []platformapi.GetAssessmentTaskFindings_2XX_Item
-	for _, v := range *findings {
-		if v.Affected {
-			filteredFindings = append(filteredFindings, v)
+	var suppressions *suppression.Result
+	if config.Suppressions != nil {
+		suppressions = config.Suppressions.Apply(report.Package, report.Platform, time.Now())
+		report.Suppressions = suppressions.Active
+		gateFindings = suppressions.Unsuppressed(gateFindings)
+		for _, s := range suppressions.Expired {
+			log.Warn().Str("CheckID", s.CheckID).Str("Expires", s.Expires).Str("Justification", s.Justification).Msg("Suppression expired")
 		}
 	}
 
-	w, err := output.New(artifactPath, output.JSON)
+	report.SeverityGate = config.SeverityGate
+	report.Violations = config.SeverityGate.Evaluate(gateFindings)
+
+	if config.Policy.Enabled() {
+		report.Policy = config.Policy.Evaluate(report.Score, gateFindings)
+		for _, rule := range report.Policy.Rules {
+			log.Debug().Str("Rule", rule.Name).Bool("Passed", rule.Passed).Str("Reason", rule.Reason).Msg("Policy rule evaluated")
+		}
+	}
+
+	report.GateError = checkGates(config, report, suppressions)
+
+	if config.FindingsArtifactPath != "" {
+		if err := writeFindings(report, config.FindingsArtifactPath, config.SARIFArtifactPath, config.OutputOptions()); err != nil {
+			log.Error().Err(err).Str("ArtifactPath", config.FindingsArtifactPath).Msg("Failed to write findings artifact")
+		}
+	}
+
+	if len(config.SaveReports) > 0 {
+		if err := output.SaveReports(ctx, config.PlatformClient, taskResponse.JSON2XX.Ref, config.SaveReports, config.ArtifactsDir); err != nil {
+			log.Error().Err(err).Str("ArtifactsDir", config.ArtifactsDir).Msg("Failed to save reports")
+		}
+	}
+
+	if config.SummaryFile != "" {
+		if err := output.AppendMarkdown(config.SummaryFile, report, config.Redactor); err != nil {
+			log.Error().Err(err).Str("SummaryFile", config.SummaryFile).Msg("Failed to write summary")
+		}
+	}
+
+	if err := w.WriteReport(report); err != nil {
+		return err
+	}
+
+	if report.GateError != nil {
+		log.Debug().Any("Task", taskResponse).Msg("Task")
+		return report.GateError
+	}
+
+	log.Info().Msg("Succeeded")
+	return nil
+}
+
+// checkGates returns the first gate the assessment fails, in order: the minimum score, the severity gate,
+// the policy, and expired suppressions
+func checkGates(config *internal.RunConfig, report *output.Report, suppressions *suppression.Result) error {
+	if !report.Passed() {
+		return fmt.Errorf("the score %.2f is less than the required minimum %d", report.Score, config.MinimumScore)
+	}
+
+	if len(report.Violations) > 0 {
+		return &gate.Error{Violations: report.Violations}
+	}
+
+	if report.Policy != nil && !report.Policy.Passed {
+		return &gate.PolicyError{Evaluation: report.Policy}
+	}
+
+	if config.FailOnExpired && suppressions != nil && len(suppressions.Expired) > 0 {
+		return &suppression.ExpiredError{Expired: suppressions.Expired}
+	}
+
+	return nil
+}
+
+func writeFindings(report *output.Report, artifactPath, sarifArtifactPath string, opts output.Options) error {
+	findings, err := report.AnnotatedAffectedFindings()
+	if err != nil {
+		return err
+	}
+
+	w, err := output.New(artifactPath, output.JSON, opts)
 	if err != nil {
 		return err
 	}
 	defer w.Close()
-	return w.Write(filteredFindings)
+	if err := w.Write(findings); err != nil {
+		return err
+	}
+
+	if sarifArtifactPath == "" {
+		return nil
+	}
+
+	sarif, err := output.New(sarifArtifactPath, output.SARIF, opts)
+	if err != nil {
+		return err
+	}
+	defer sarif.Close()
+	return sarif.WriteReport(report)
 }
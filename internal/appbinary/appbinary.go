@@ -0,0 +1,157 @@
+package appbinary
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+type Kind string
+
+const (
+	APK Kind = "apk"
+	AAB Kind = "aab"
+	IPA Kind = "ipa"
+)
+
+// maxManifestSize bounds how much of a manifest is decompressed, so a malicious archive can't exhaust memory
+const maxManifestSize = 16 << 20
+
+var zipMagic = []byte("PK\x03\x04")
+
+// ErrUnrecognized matches the errors of Inspect for a file that isn't structured as an APK, AAB or IPA at all, as
+// opposed to a binary whose manifest can't be read
+var ErrUnrecognized = errors.New("not an APK, AAB or IPA")
+
+// unrecognized makes err match ErrUnrecognized without changing its message
+type unrecognized struct{ error }
+
+func (unrecognized) Is(target error) bool { return target == ErrUnrecognized }
+
+func (e unrecognized) Unwrap() error { return errors.Unwrap(e.error) }
+
+// Info identifies a mobile binary from its own metadata
+type Info struct {
+	Kind     Kind   `json:"kind"`
+	Platform string `json:"platform"`
+	// Package is the Android package name or iOS bundle ID
+	Package string `json:"package"`
+	Version string `json:"version,omitempty"`
+}
+
+// Inspect identifies an APK, AAB or IPA by its zip structure and reads the package name and version from its
+// AndroidManifest.xml or Info.plist, without relying on the file name
+func Inspect(r io.ReaderAt, size int64) (*Info, error) {
+	magic := make([]byte, len(zipMagic))
+	if _, err := r.ReadAt(magic, 0); err != nil || !bytes.Equal(magic, zipMagic) {
+		return nil, unrecognized{errors.New("not a zip archive")}
+	}
+
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, unrecognized{fmt.Errorf("invalid zip archive: %w", err)}
+	}
+
+	for _, f := range archive.File {
+		switch {
+		case f.Name == "AndroidManifest.xml":
+			return inspectAndroid(f, APK, parseBinaryManifest)
+		case f.Name == "base/manifest/AndroidManifest.xml":
+			return inspectAndroid(f, AAB, parseProtoManifest)
+		case isInfoPlist(f.Name):
+			return inspectIOS(f)
+		}
+	}
+
+	return nil, unrecognized{errors.New("archive contains neither an AndroidManifest.xml nor a Payload/*.app/Info.plist")}
+}
+
+// isInfoPlist matches the Info.plist of the application bundle, not those of nested frameworks or extensions
+func isInfoPlist(name string) bool {
+	dir, file := path.Split(name)
+	if file != "Info.plist" {
+		return false
+	}
+
+	parts := strings.Split(strings.TrimSuffix(dir, "/"), "/")
+	return len(parts) == 2 && parts[0] == "Payload" && strings.HasSuffix(parts[1], ".app")
+}
+
+func inspectAndroid(f *zip.File, kind Kind, parse func([]byte) (map[string]string, error)) (*Info, error) {
+	data, err := readFile(f)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", f.Name, err)
+	}
+
+	info := &Info{
+		Kind:     kind,
+		Platform: "android",
+		Package:  attributes["package"],
+		Version:  attributes["versionName"],
+	}
+	if info.Version == "" {
+		info.Version = attributes["versionCode"]
+	}
+
+	if info.Package == "" {
+		return nil, fmt.Errorf("%s does not declare a package name", f.Name)
+	}
+
+	return info, nil
+}
+
+func inspectIOS(f *zip.File) (*Info, error) {
+	data, err := readFile(f)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := parsePlist(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", f.Name, err)
+	}
+
+	info := &Info{
+		Kind:     IPA,
+		Platform: "ios",
+		Package:  values["CFBundleIdentifier"],
+		Version:  values["CFBundleShortVersionString"],
+	}
+	if info.Version == "" {
+		info.Version = values["CFBundleVersion"]
+	}
+
+	if info.Package == "" {
+		return nil, fmt.Errorf("%s does not declare a CFBundleIdentifier", f.Name)
+	}
+
+	return info, nil
+}
+
+func readFile(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxManifestSize {
+		return nil, fmt.Errorf("%s is too large to be a manifest", f.Name)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxManifestSize))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,134 @@
+package appbinary
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildZip(t *testing.T, files map[string][]byte) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, contents := range files {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write(contents)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestIsInfoPlist(t *testing.T) {
+	tests := map[string]bool{
+		"Payload/Example.app/Info.plist":                          true,
+		"Payload/Example.app/Frameworks/Lib.framework/Info.plist": false,
+		"Payload/Example.app/PlugIns/Share.appex/Info.plist":      false,
+		"Payload/Info.plist":                                      false,
+		"Example.app/Info.plist":                                  false,
+		"Payload/Example/Info.plist":                              false,
+	}
+
+	for name, expected := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, expected, isInfoPlist(name))
+		})
+	}
+}
+
+func TestInspect(t *testing.T) {
+	apkManifest := buildAXML([]string{"manifest", "package", "versionCode", "com.example.apk"}, true, nil, 0, []axmlAttribute{
+		stringAttribute(1, 3),
+		{name: 2, rawValue: noEntry, dataType: typeIntDec, data: 7},
+	})
+	plist := []byte(`<plist><dict><key>CFBundleIdentifier</key><string>com.example.ipa</string>` +
+		`<key>CFBundleVersion</key><string>99</string></dict></plist>`)
+
+	tests := []struct {
+		name     string
+		files    map[string][]byte
+		expected *Info
+		err      string
+	}{
+		{
+			name:     "APK falls back to the version code",
+			files:    map[string][]byte{"AndroidManifest.xml": apkManifest},
+			expected: &Info{Kind: APK, Platform: "android", Package: "com.example.apk", Version: "7"},
+		},
+		{
+			name:     "AAB",
+			files:    map[string][]byte{"base/manifest/AndroidManifest.xml": protoManifest("manifest", protoAttribute("package", "com.example.aab"))},
+			expected: &Info{Kind: AAB, Platform: "android", Package: "com.example.aab"},
+		},
+		{
+			name: "IPA falls back to the bundle version",
+			files: map[string][]byte{
+				"Payload/Example.app/Frameworks/Lib.framework/Info.plist": []byte("<plist/>"),
+				"Payload/Example.app/Info.plist":                          plist,
+			},
+			expected: &Info{Kind: IPA, Platform: "ios", Package: "com.example.ipa", Version: "99"},
+		},
+		{
+			name:  "Manifest without a package",
+			files: map[string][]byte{"AndroidManifest.xml": buildAXML([]string{"manifest"}, false, nil, 0, nil)},
+			err:   "AndroidManifest.xml does not declare a package name",
+		},
+		{
+			name:  "Info.plist without a bundle ID",
+			files: map[string][]byte{"Payload/Example.app/Info.plist": []byte("<plist><dict></dict></plist>")},
+			err:   "Payload/Example.app/Info.plist does not declare a CFBundleIdentifier",
+		},
+		{
+			name:  "Malformed manifest",
+			files: map[string][]byte{"AndroidManifest.xml": []byte("<manifest/>")},
+			err:   "invalid AndroidManifest.xml: not binary XML",
+		},
+		{
+			name:  "No manifest",
+			files: map[string][]byte{"classes.dex": []byte("dex\n035\x00")},
+			err:   "archive contains neither an AndroidManifest.xml nor a Payload/*.app/Info.plist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := buildZip(t, tt.files)
+			info, err := Inspect(r, r.Size())
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				// Only a zip archive without any manifest isn't recognized, a malformed one is
+				assert.Equal(t, tt.name == "No manifest", errors.Is(err, ErrUnrecognized))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, info)
+		})
+	}
+
+	t.Run("Not a zip archive", func(t *testing.T) {
+		for _, data := range []string{"", "PK", "not a zip archive at all"} {
+			r := bytes.NewReader([]byte(data))
+			_, err := Inspect(r, r.Size())
+			require.EqualError(t, err, "not a zip archive")
+			assert.ErrorIs(t, err, ErrUnrecognized)
+		}
+	})
+
+	t.Run("Truncated zip archive", func(t *testing.T) {
+		data := buildZip(t, map[string][]byte{"AndroidManifest.xml": apkManifest})
+		truncated := make([]byte, data.Size()/2)
+		_, err := data.ReadAt(truncated, 0)
+		require.NoError(t, err)
+
+		r := bytes.NewReader(truncated)
+		_, err = Inspect(r, r.Size())
+		require.ErrorContains(t, err, "invalid zip archive")
+		assert.ErrorIs(t, err, ErrUnrecognized)
+	})
+}
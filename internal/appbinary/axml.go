@@ -0,0 +1,222 @@
+package appbinary
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"unicode/utf16"
+)
+
+// Chunk types and constants of the Android binary XML format, see ResourceTypes.h in the Android framework
+const (
+	resStringPoolType   = 0x0001
+	resXMLType          = 0x0003
+	resXMLStartElement  = 0x0102
+	resXMLResourceMap   = 0x0180
+	stringPoolUTF8Flag  = 1 << 8
+	noEntry             = 0xffffffff
+	typeString          = 0x03
+	typeIntDec          = 0x10
+	typeIntHex          = 0x11
+	attrVersionCode     = 0x0101021b
+	attrVersionName     = 0x0101021c
+	startElementMinSize = 36
+)
+
+var errTruncated = errors.New("truncated binary XML")
+
+// parseBinaryManifest returns the attributes of the root <manifest> element of a compiled AndroidManifest.xml
+func parseBinaryManifest(data []byte) (map[string]string, error) {
+	if len(data) < 8 || binary.LittleEndian.Uint16(data) != resXMLType {
+		return nil, errors.New("not binary XML")
+	}
+
+	var pool []string
+	var resourceIDs []uint32
+	offset := int(binary.LittleEndian.Uint16(data[2:]))
+	for offset+8 <= len(data) {
+		chunkType := binary.LittleEndian.Uint16(data[offset:])
+		chunkSize := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		if chunkSize < 8 || offset+chunkSize > len(data) {
+			return nil, errTruncated
+		}
+		chunk := data[offset : offset+chunkSize]
+
+		switch chunkType {
+		case resStringPoolType:
+			var err error
+			if pool, err = parseStringPool(chunk); err != nil {
+				return nil, err
+			}
+		case resXMLResourceMap:
+			headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+			for i := headerSize; i+4 <= len(chunk); i += 4 {
+				resourceIDs = append(resourceIDs, binary.LittleEndian.Uint32(chunk[i:]))
+			}
+		case resXMLStartElement:
+			// The first element of a manifest is <manifest>
+			return parseStartElement(chunk, pool, resourceIDs)
+		}
+
+		offset += chunkSize
+	}
+
+	return nil, errors.New("no manifest element")
+}
+
+func parseStartElement(chunk []byte, pool []string, resourceIDs []uint32) (map[string]string, error) {
+	if len(chunk) < startElementMinSize {
+		return nil, errTruncated
+	}
+
+	headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+	if headerSize+20 > len(chunk) {
+		return nil, errTruncated
+	}
+	ext := chunk[headerSize:]
+
+	if name := poolString(pool, binary.LittleEndian.Uint32(ext[4:])); name != "manifest" {
+		return nil, errors.New("root element is " + strconv.Quote(name) + ", not manifest")
+	}
+
+	attributeStart := int(binary.LittleEndian.Uint16(ext[8:]))
+	attributeSize := int(binary.LittleEndian.Uint16(ext[10:]))
+	attributeCount := int(binary.LittleEndian.Uint16(ext[12:]))
+	if attributeSize < 20 {
+		return nil, errTruncated
+	}
+
+	attributes := map[string]string{}
+	for i := 0; i < attributeCount; i++ {
+		start := headerSize + attributeStart + i*attributeSize
+		if start+20 > len(chunk) {
+			return nil, errTruncated
+		}
+		attr := chunk[start:]
+
+		nameIndex := binary.LittleEndian.Uint32(attr[4:])
+		name := poolString(pool, nameIndex)
+		// Obfuscated manifests can strip attribute names, leaving only the framework resource ID
+		if uint64(nameIndex) < uint64(len(resourceIDs)) {
+			switch resourceIDs[nameIndex] {
+			case attrVersionCode:
+				name = "versionCode"
+			case attrVersionName:
+				name = "versionName"
+			}
+		}
+
+		rawValue := binary.LittleEndian.Uint32(attr[8:])
+		dataType := attr[15]
+		value := binary.LittleEndian.Uint32(attr[16:])
+
+		switch {
+		case rawValue != noEntry:
+			attributes[name] = poolString(pool, rawValue)
+		case dataType == typeString:
+			attributes[name] = poolString(pool, value)
+		case dataType == typeIntDec || dataType == typeIntHex:
+			attributes[name] = strconv.FormatUint(uint64(value), 10)
+		}
+	}
+
+	return attributes, nil
+}
+
+func parseStringPool(chunk []byte) ([]string, error) {
+	if len(chunk) < 28 {
+		return nil, errTruncated
+	}
+
+	headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+	count := int(binary.LittleEndian.Uint32(chunk[8:]))
+	flags := binary.LittleEndian.Uint32(chunk[16:])
+	stringsStart := int(binary.LittleEndian.Uint32(chunk[20:]))
+	if headerSize+count*4 > len(chunk) || stringsStart > len(chunk) {
+		return nil, errTruncated
+	}
+
+	pool := make([]string, count)
+	for i := range pool {
+		offset := stringsStart + int(binary.LittleEndian.Uint32(chunk[headerSize+i*4:]))
+		if offset >= len(chunk) {
+			return nil, errTruncated
+		}
+
+		var err error
+		if flags&stringPoolUTF8Flag != 0 {
+			pool[i], err = utf8PoolString(chunk[offset:])
+		} else {
+			pool[i], err = utf16PoolString(chunk[offset:])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pool, nil
+}
+
+// utf8PoolString decodes a string prefixed by its UTF-16 and UTF-8 lengths, each one or two bytes long
+func utf8PoolString(b []byte) (string, error) {
+	_, n := utf8PoolLength(b)
+	if n == 0 {
+		return "", errTruncated
+	}
+	length, m := utf8PoolLength(b[n:])
+	if m == 0 || n+m+length > len(b) {
+		return "", errTruncated
+	}
+
+	return string(b[n+m : n+m+length]), nil
+}
+
+func utf8PoolLength(b []byte) (int, int) {
+	if len(b) < 1 {
+		return 0, 0
+	}
+	if b[0]&0x80 == 0 {
+		return int(b[0]), 1
+	}
+	if len(b) < 2 {
+		return 0, 0
+	}
+
+	return int(b[0]&0x7f)<<8 | int(b[1]), 2
+}
+
+// utf16PoolString decodes a string prefixed by its length in UTF-16 code units, two or four bytes long
+func utf16PoolString(b []byte) (string, error) {
+	if len(b) < 2 {
+		return "", errTruncated
+	}
+
+	length := int(binary.LittleEndian.Uint16(b))
+	start := 2
+	if length&0x8000 != 0 {
+		if len(b) < 4 {
+			return "", errTruncated
+		}
+		length = (length&0x7fff)<<16 | int(binary.LittleEndian.Uint16(b[2:]))
+		start = 4
+	}
+
+	if start+length*2 > len(b) {
+		return "", errTruncated
+	}
+
+	units := make([]uint16, length)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[start+i*2:])
+	}
+
+	return string(utf16.Decode(units)), nil
+}
+
+func poolString(pool []string, index uint32) string {
+	if uint64(index) >= uint64(len(pool)) {
+		return ""
+	}
+
+	return pool[index]
+}
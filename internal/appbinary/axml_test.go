@@ -0,0 +1,217 @@
+package appbinary
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type axmlAttribute struct {
+	name     uint32
+	rawValue uint32
+	dataType byte
+	data     uint32
+}
+
+// stringAttribute is an attribute whose value is the string at index value of the pool
+func stringAttribute(name, value uint32) axmlAttribute {
+	return axmlAttribute{name: name, rawValue: value, dataType: typeString, data: value}
+}
+
+// buildAXML compiles a binary XML document of a single element named by the string at index name of the pool
+func buildAXML(pool []string, utf8 bool, resourceIDs []uint32, name uint32, attributes []axmlAttribute) []byte {
+	le := binary.LittleEndian
+
+	var offsets, data []byte
+	for _, s := range pool {
+		offsets = le.AppendUint32(offsets, uint32(len(data)))
+		units := utf16.Encode([]rune(s))
+		if utf8 {
+			data = append(data, byte(len(units)), byte(len(s)))
+			data = append(data, s...)
+			data = append(data, 0)
+			continue
+		}
+		data = le.AppendUint16(data, uint16(len(units)))
+		for _, u := range units {
+			data = le.AppendUint16(data, u)
+		}
+		data = le.AppendUint16(data, 0)
+	}
+	for len(data)%4 != 0 {
+		data = append(data, 0)
+	}
+
+	var flags uint32
+	if utf8 {
+		flags = stringPoolUTF8Flag
+	}
+	chunk := le.AppendUint16(nil, resStringPoolType)
+	chunk = le.AppendUint16(chunk, 28)
+	chunk = le.AppendUint32(chunk, uint32(28+len(offsets)+len(data)))
+	chunk = le.AppendUint32(chunk, uint32(len(pool)))
+	chunk = le.AppendUint32(chunk, 0)
+	chunk = le.AppendUint32(chunk, flags)
+	chunk = le.AppendUint32(chunk, uint32(28+len(offsets)))
+	chunk = le.AppendUint32(chunk, 0)
+	body := append(append(chunk, offsets...), data...)
+
+	if len(resourceIDs) > 0 {
+		resourceMap := le.AppendUint16(nil, resXMLResourceMap)
+		resourceMap = le.AppendUint16(resourceMap, 8)
+		resourceMap = le.AppendUint32(resourceMap, uint32(8+4*len(resourceIDs)))
+		for _, id := range resourceIDs {
+			resourceMap = le.AppendUint32(resourceMap, id)
+		}
+		body = append(body, resourceMap...)
+	}
+
+	element := le.AppendUint16(nil, resXMLStartElement)
+	element = le.AppendUint16(element, 16)
+	element = le.AppendUint32(element, uint32(16+20+20*len(attributes)))
+	element = le.AppendUint32(element, 1)
+	element = le.AppendUint32(element, noEntry)
+	element = le.AppendUint32(element, noEntry)
+	element = le.AppendUint32(element, name)
+	element = le.AppendUint16(element, 20)
+	element = le.AppendUint16(element, 20)
+	element = le.AppendUint16(element, uint16(len(attributes)))
+	element = le.AppendUint16(element, 0)
+	element = le.AppendUint16(element, 0)
+	element = le.AppendUint16(element, 0)
+	for _, attribute := range attributes {
+		element = le.AppendUint32(element, noEntry)
+		element = le.AppendUint32(element, attribute.name)
+		element = le.AppendUint32(element, attribute.rawValue)
+		element = le.AppendUint16(element, 8)
+		element = append(element, 0, attribute.dataType)
+		element = le.AppendUint32(element, attribute.data)
+	}
+	body = append(body, element...)
+
+	document := le.AppendUint16(nil, resXMLType)
+	document = le.AppendUint16(document, 8)
+	document = le.AppendUint32(document, uint32(8+len(body)))
+	return append(document, body...)
+}
+
+func TestParseBinaryManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected map[string]string
+	}{
+		{
+			name: "UTF-16 string pool",
+			data: buildAXML([]string{"manifest", "package", "versionName", "com.example.app", "1.2.3"}, false, nil, 0,
+				[]axmlAttribute{stringAttribute(1, 3), stringAttribute(2, 4)}),
+			expected: map[string]string{"package": "com.example.app", "versionName": "1.2.3"},
+		},
+		{
+			name: "UTF-8 string pool",
+			data: buildAXML([]string{"manifest", "package", "versionName", "com.example.app", "1.2.3"}, true, nil, 0,
+				[]axmlAttribute{stringAttribute(1, 3), stringAttribute(2, 4)}),
+			expected: map[string]string{"package": "com.example.app", "versionName": "1.2.3"},
+		},
+		{
+			name: "Non-ASCII strings",
+			data: buildAXML([]string{"manifest", "package", "versionName", "com.example.app", "1.0-β"}, false, nil, 0,
+				[]axmlAttribute{stringAttribute(1, 3), stringAttribute(2, 4)}),
+			expected: map[string]string{"package": "com.example.app", "versionName": "1.0-β"},
+		},
+		{
+			name: "Typed values",
+			data: buildAXML([]string{"manifest", "package", "versionCode", "com.example.app"}, false, nil, 0,
+				[]axmlAttribute{
+					{name: 1, rawValue: noEntry, dataType: typeString, data: 3},
+					{name: 2, rawValue: noEntry, dataType: typeIntDec, data: 42},
+				}),
+			expected: map[string]string{"package": "com.example.app", "versionCode": "42"},
+		},
+		{
+			name: "Obfuscated attribute names are resolved from the resource map",
+			data: buildAXML([]string{"", "", "manifest", "package", "com.example.app", "2.0"}, false,
+				[]uint32{attrVersionCode, attrVersionName}, 2,
+				[]axmlAttribute{
+					stringAttribute(3, 4),
+					{name: 0, rawValue: noEntry, dataType: typeIntHex, data: 0x10},
+					stringAttribute(1, 5),
+				}),
+			expected: map[string]string{"package": "com.example.app", "versionCode": "16", "versionName": "2.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attributes, err := parseBinaryManifest(tt.data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, attributes)
+		})
+	}
+}
+
+func TestParseBinaryManifestMalformed(t *testing.T) {
+	valid := buildAXML([]string{"manifest", "package", "com.example.app"}, false, nil, 0, []axmlAttribute{stringAttribute(1, 2)})
+
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{name: "Empty", data: nil, err: "not binary XML"},
+		{name: "Text XML", data: []byte(`<?xml version="1.0"?><manifest package="a"/>`), err: "not binary XML"},
+		{name: "Other root element", data: buildAXML([]string{"application"}, false, nil, 0, nil), err: `root element is "application", not manifest`},
+		{name: "No elements", data: valid[:8], err: "no manifest element"},
+		{
+			name: "Chunk larger than the document",
+			data: func() []byte {
+				data := append([]byte(nil), valid...)
+				binary.LittleEndian.PutUint32(data[12:], uint32(len(data)))
+				return data
+			}(),
+			err: errTruncated.Error(),
+		},
+		{
+			name: "String offset past the pool",
+			data: func() []byte {
+				data := append([]byte(nil), valid...)
+				binary.LittleEndian.PutUint32(data[8+28:], 0xffff)
+				return data
+			}(),
+			err: errTruncated.Error(),
+		},
+		{
+			name: "Attribute count past the element",
+			data: func() []byte {
+				data := append([]byte(nil), valid...)
+				// The attribute count of the only element, which ends the document
+				binary.LittleEndian.PutUint16(data[len(data)-20-8:], 100)
+				return data
+			}(),
+			err: errTruncated.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseBinaryManifest(tt.data)
+			require.EqualError(t, err, tt.err)
+		})
+	}
+
+	t.Run("Truncated anywhere", func(t *testing.T) {
+		for n := range len(valid) {
+			_, err := parseBinaryManifest(valid[:n])
+			assert.Error(t, err, "truncated to %d bytes", n)
+		}
+	})
+
+	t.Run("Out of range pool indexes read as empty", func(t *testing.T) {
+		attributes, err := parseBinaryManifest(buildAXML([]string{"manifest", "package"}, false, nil, 0, []axmlAttribute{stringAttribute(1, 99)}))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"package": ""}, attributes)
+	})
+}
@@ -0,0 +1,226 @@
+package appbinary
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"io"
+	"unicode/utf16"
+)
+
+const (
+	bplistMagic       = "bplist00"
+	bplistTrailerSize = 32
+	bplistString      = 0x5
+	bplistUTF16String = 0x6
+	bplistDict        = 0xd
+)
+
+var errMalformedPlist = errors.New("malformed binary plist")
+
+// parsePlist returns the string values of the top level dictionary of an XML or binary property list
+func parsePlist(data []byte) (map[string]string, error) {
+	if bytes.HasPrefix(data, []byte(bplistMagic)) {
+		return parseBinaryPlist(data)
+	}
+
+	return parseXMLPlist(data)
+}
+
+func parseXMLPlist(data []byte) (map[string]string, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	// Info.plist files are UTF-8 in practice, and the charset is irrelevant to the ASCII keys we read
+	d.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+
+	if err := seekElement(d, "dict"); err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	var key string
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "key":
+				if err := d.DecodeElement(&key, &t); err != nil {
+					return nil, err
+				}
+			case "string":
+				var value string
+				if err := d.DecodeElement(&value, &t); err != nil {
+					return nil, err
+				}
+				values[key] = value
+			default:
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			// The end of the top level dictionary
+			return values, nil
+		}
+	}
+}
+
+func seekElement(d *xml.Decoder, name string) error {
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return errors.New("no " + name + " element")
+		}
+		if err != nil {
+			return err
+		}
+
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == name {
+			return nil
+		}
+	}
+}
+
+// binaryPlist reads objects of the bplist00 format, see CFBinaryPList.c in CoreFoundation
+type binaryPlist struct {
+	data       []byte
+	offsetSize int
+	refSize    int
+	numObjects uint64
+	offsets    uint64
+}
+
+func parseBinaryPlist(data []byte) (map[string]string, error) {
+	if len(data) < len(bplistMagic)+bplistTrailerSize {
+		return nil, errMalformedPlist
+	}
+
+	trailer := data[len(data)-bplistTrailerSize:]
+	p := &binaryPlist{
+		data:       data,
+		offsetSize: int(trailer[6]),
+		refSize:    int(trailer[7]),
+		numObjects: binary.BigEndian.Uint64(trailer[8:]),
+		offsets:    binary.BigEndian.Uint64(trailer[24:]),
+	}
+	top := binary.BigEndian.Uint64(trailer[16:])
+
+	if p.offsetSize < 1 || p.offsetSize > 8 || p.refSize < 1 || p.refSize > 8 {
+		return nil, errMalformedPlist
+	}
+
+	marker, start, count, err := p.object(top)
+	if err != nil {
+		return nil, err
+	}
+	if marker != bplistDict {
+		return nil, errors.New("top level object is not a dictionary")
+	}
+	if count > p.numObjects {
+		return nil, errMalformedPlist
+	}
+
+	values := map[string]string{}
+	for i := uint64(0); i < count; i++ {
+		keyRef, err := p.uint(start+i*uint64(p.refSize), p.refSize)
+		if err != nil {
+			return nil, err
+		}
+		valueRef, err := p.uint(start+(count+i)*uint64(p.refSize), p.refSize)
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok, err := p.string(keyRef)
+		if err != nil || !ok {
+			return nil, errMalformedPlist
+		}
+
+		value, ok, err := p.string(valueRef)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			values[key] = value
+		}
+	}
+
+	return values, nil
+}
+
+// object returns the type, start of the contents and length of an object
+func (p *binaryPlist) object(ref uint64) (byte, uint64, uint64, error) {
+	if ref >= p.numObjects {
+		return 0, 0, 0, errMalformedPlist
+	}
+
+	offset, err := p.uint(p.offsets+ref*uint64(p.offsetSize), p.offsetSize)
+	if err != nil || offset >= uint64(len(p.data)) {
+		return 0, 0, 0, errMalformedPlist
+	}
+
+	marker := p.data[offset]
+	kind, count, start := marker>>4, uint64(marker&0xf), offset+1
+	if count == 0xf {
+		// The length doesn't fit the marker and follows it as an integer object
+		if start >= uint64(len(p.data)) || p.data[start]>>4 != 0x1 {
+			return 0, 0, 0, errMalformedPlist
+		}
+		size := 1 << (p.data[start] & 0xf)
+		if size > 8 {
+			return 0, 0, 0, errMalformedPlist
+		}
+		if count, err = p.uint(start+1, size); err != nil {
+			return 0, 0, 0, err
+		}
+		start += 1 + uint64(size)
+	}
+
+	return kind, start, count, nil
+}
+
+// string returns the value of a string object, or false if the object is of another type
+func (p *binaryPlist) string(ref uint64) (string, bool, error) {
+	kind, start, count, err := p.object(ref)
+	if err != nil {
+		return "", false, err
+	}
+
+	switch kind {
+	case bplistString:
+		if count > uint64(len(p.data)) || start+count > uint64(len(p.data)) {
+			return "", false, errMalformedPlist
+		}
+		return string(p.data[start : start+count]), true, nil
+	case bplistUTF16String:
+		if count > uint64(len(p.data)) || start+count*2 > uint64(len(p.data)) {
+			return "", false, errMalformedPlist
+		}
+		units := make([]uint16, count)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(p.data[start+uint64(i)*2:])
+		}
+		return string(utf16.Decode(units)), true, nil
+	}
+
+	return "", false, nil
+}
+
+// uint reads a big endian unsigned integer of 1 to 8 bytes
+func (p *binaryPlist) uint(offset uint64, size int) (uint64, error) {
+	if offset > uint64(len(p.data)) || offset+uint64(size) > uint64(len(p.data)) {
+		return 0, errMalformedPlist
+	}
+
+	var v uint64
+	for _, b := range p.data[offset : offset+uint64(size)] {
+		v = v<<8 | uint64(b)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,165 @@
+package appbinary
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bplistASCII encodes an ASCII string object, with its length as a following integer object past 14 characters
+func bplistASCII(s string) []byte {
+	if len(s) < 0xf {
+		return append([]byte{bplistString<<4 | byte(len(s))}, s...)
+	}
+
+	return append([]byte{bplistString<<4 | 0xf, 0x10, byte(len(s))}, s...)
+}
+
+func bplistUTF16(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	object := []byte{bplistUTF16String<<4 | byte(len(units))}
+	for _, u := range units {
+		object = binary.BigEndian.AppendUint16(object, u)
+	}
+
+	return object
+}
+
+// buildBinaryPlist writes a bplist00 document of objects, with one byte offsets and references, whose top level
+// object is the first
+func buildBinaryPlist(objects ...[]byte) []byte {
+	data := []byte(bplistMagic)
+	var offsets []byte
+	for _, object := range objects {
+		offsets = append(offsets, byte(len(data)))
+		data = append(data, object...)
+	}
+	offsetTable := len(data)
+	data = append(data, offsets...)
+
+	trailer := make([]byte, 6, bplistTrailerSize)
+	trailer = append(trailer, 1, 1)
+	trailer = binary.BigEndian.AppendUint64(trailer, uint64(len(objects)))
+	trailer = binary.BigEndian.AppendUint64(trailer, 0)
+	trailer = binary.BigEndian.AppendUint64(trailer, uint64(offsetTable))
+
+	return append(data, trailer...)
+}
+
+// bplistDictionary is a dictionary object of count keys, referencing the objects following it as key value pairs
+func bplistDictionary(count int) []byte {
+	object := []byte{bplistDict<<4 | byte(count)}
+	for i := range count {
+		object = append(object, byte(1+2*i))
+	}
+	for i := range count {
+		object = append(object, byte(2+2*i))
+	}
+
+	return object
+}
+
+func TestParsePlist(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected map[string]string
+	}{
+		{
+			name: "XML",
+			data: []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleIcons</key>
+	<dict>
+		<key>CFBundleIdentifier</key>
+		<string>nested</string>
+	</dict>
+	<key>CFBundleIdentifier</key>
+	<string>com.example.app</string>
+	<key>UIRequiredDeviceCapabilities</key>
+	<array><string>arm64</string></array>
+	<key>LSRequiresIPhoneOS</key>
+	<true/>
+	<key>CFBundleShortVersionString</key>
+	<string>1.2.3</string>
+</dict>
+</plist>`),
+			expected: map[string]string{"CFBundleIdentifier": "com.example.app", "CFBundleShortVersionString": "1.2.3"},
+		},
+		{
+			name:     "XML in another declared charset",
+			data:     []byte(`<?xml version="1.0" encoding="ISO-8859-1"?><plist><dict><key>CFBundleVersion</key><string>42</string></dict></plist>`),
+			expected: map[string]string{"CFBundleVersion": "42"},
+		},
+		{
+			name: "Binary",
+			data: buildBinaryPlist(
+				bplistDictionary(3),
+				bplistASCII("CFBundleIdentifier"), bplistASCII("com.example.app"),
+				bplistASCII("LSRequiresIPhoneOS"), []byte{0x09},
+				bplistASCII("CFBundleDisplayName"), bplistUTF16("Exämple"),
+			),
+			expected: map[string]string{"CFBundleIdentifier": "com.example.app", "CFBundleDisplayName": "Exämple"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := parsePlist(tt.data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, values)
+		})
+	}
+}
+
+func TestParsePlistMalformed(t *testing.T) {
+	valid := buildBinaryPlist(bplistDictionary(1), bplistASCII("CFBundleIdentifier"), bplistASCII("com.example.app"))
+
+	corrupt := func(offset int, value byte) []byte {
+		data := append([]byte(nil), valid...)
+		data[offset] = value
+		return data
+	}
+	trailer := len(valid) - bplistTrailerSize
+
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{name: "Empty", data: nil, err: "no dict element"},
+		{name: "XML without a dictionary", data: []byte(`<plist><array/></plist>`), err: "no dict element"},
+		{name: "Unterminated XML", data: []byte(`<plist><dict><key>CFBundleIdentifier</key>`), err: "XML syntax error on line 1: unexpected EOF"},
+		{name: "Binary without a trailer", data: []byte(bplistMagic + "\x00"), err: errMalformedPlist.Error()},
+		{name: "Top level object is not a dictionary", data: buildBinaryPlist(bplistASCII("CFBundleIdentifier")), err: "top level object is not a dictionary"},
+		{name: "Invalid offset size", data: corrupt(trailer+6, 9), err: errMalformedPlist.Error()},
+		{name: "Invalid reference size", data: corrupt(trailer+7, 0), err: errMalformedPlist.Error()},
+		{name: "Top level object out of range", data: corrupt(trailer+23, 3), err: errMalformedPlist.Error()},
+		{name: "Offset table out of range", data: corrupt(len(valid)-1, 0xff), err: errMalformedPlist.Error()},
+		{name: "Reference out of range", data: corrupt(len(bplistMagic)+1, 7), err: errMalformedPlist.Error()},
+		{name: "Key is not a string", data: corrupt(len(bplistMagic)+2, 0x09), err: errMalformedPlist.Error()},
+		// The length of the value, following the dictionary and the 21 bytes of the key
+		{name: "String past the end", data: corrupt(len(bplistMagic)+3+21+2, 0xff), err: errMalformedPlist.Error()},
+		{name: "Dictionary larger than the object count", data: corrupt(len(bplistMagic), bplistDict<<4|0xe), err: errMalformedPlist.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parsePlist(tt.data)
+			require.EqualError(t, err, tt.err)
+		})
+	}
+
+	t.Run("Truncated anywhere", func(t *testing.T) {
+		for n := len(bplistMagic); n < len(valid); n++ {
+			assert.NotPanics(t, func() {
+				_, _ = parsePlist(valid[:n])
+			}, "truncated to %d bytes", n)
+		}
+	})
+}
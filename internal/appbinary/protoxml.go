@@ -0,0 +1,117 @@
+package appbinary
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Field numbers of the XmlNode, XmlElement and XmlAttribute messages of aapt2's Resources.proto, the format of
+// the manifest in an Android App Bundle
+const (
+	xmlNodeElement      = 1
+	xmlElementName      = 3
+	xmlElementAttribute = 4
+	xmlAttributeName    = 2
+	xmlAttributeValue   = 3
+	protoWireVarint     = 0
+	protoWireFixed64    = 1
+	protoWireBytes      = 2
+	protoWireFixed32    = 5
+)
+
+var errMalformedProto = errors.New("malformed protobuf XML")
+
+// parseProtoManifest returns the attributes of the root <manifest> element of a bundle's AndroidManifest.xml
+func parseProtoManifest(data []byte) (map[string]string, error) {
+	var element []byte
+	err := protoFields(data, func(field int, value []byte) {
+		if field == xmlNodeElement {
+			element = value
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if element == nil {
+		return nil, errors.New("no manifest element")
+	}
+
+	var name string
+	var attributes [][]byte
+	err = protoFields(element, func(field int, value []byte) {
+		switch field {
+		case xmlElementName:
+			name = string(value)
+		case xmlElementAttribute:
+			attributes = append(attributes, value)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if name != "manifest" {
+		return nil, errors.New("root element is not manifest")
+	}
+
+	result := map[string]string{}
+	for _, attribute := range attributes {
+		var attrName, attrValue string
+		err := protoFields(attribute, func(field int, value []byte) {
+			switch field {
+			case xmlAttributeName:
+				attrName = string(value)
+			case xmlAttributeValue:
+				attrValue = string(value)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		result[attrName] = attrValue
+	}
+
+	return result, nil
+}
+
+// protoFields calls fn with every length-delimited field of a message, skipping fields of other wire types
+func protoFields(data []byte, fn func(field int, value []byte)) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errMalformedProto
+		}
+		data = data[n:]
+
+		switch key & 7 {
+		case protoWireVarint:
+			_, n := binary.Uvarint(data)
+			if n <= 0 {
+				return errMalformedProto
+			}
+			data = data[n:]
+		case protoWireFixed64:
+			if len(data) < 8 {
+				return errMalformedProto
+			}
+			data = data[8:]
+		case protoWireFixed32:
+			if len(data) < 4 {
+				return errMalformedProto
+			}
+			data = data[4:]
+		case protoWireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return errMalformedProto
+			}
+			fn(int(key>>3), data[n:n+int(length)])
+			data = data[n+int(length):]
+		default:
+			return errMalformedProto
+		}
+	}
+
+	return nil
+}
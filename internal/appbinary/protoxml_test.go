@@ -0,0 +1,117 @@
+package appbinary
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func protoField(b []byte, number uint64, value []byte) []byte {
+	b = binary.AppendUvarint(b, number<<3|protoWireBytes)
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+func protoAttribute(name, value string) []byte {
+	return protoField(protoField(nil, xmlAttributeName, []byte(name)), xmlAttributeValue, []byte(value))
+}
+
+func protoManifest(name string, attributes ...[]byte) []byte {
+	element := protoField(nil, xmlElementName, []byte(name))
+	for _, attribute := range attributes {
+		element = protoField(element, xmlElementAttribute, attribute)
+	}
+
+	return protoField(nil, xmlNodeElement, element)
+}
+
+func TestParseProtoManifest(t *testing.T) {
+	withOtherWireTypes := func() []byte {
+		// A namespace URI, a source position varint, and fixed width fields around the attributes
+		attribute := protoField(nil, 1, []byte("http://schemas.android.com/apk/res/android"))
+		attribute = append(attribute, protoAttribute("versionName", "3.0")...)
+		attribute = binary.AppendUvarint(attribute, 5<<3|protoWireVarint)
+		attribute = binary.AppendUvarint(attribute, 300)
+
+		element := binary.AppendUvarint(nil, 6<<3|protoWireFixed64)
+		element = append(element, make([]byte, 8)...)
+		element = protoField(element, xmlElementName, []byte("manifest"))
+		element = protoField(element, xmlElementAttribute, protoAttribute("package", "com.example.app"))
+		element = protoField(element, xmlElementAttribute, attribute)
+		element = binary.AppendUvarint(element, 7<<3|protoWireFixed32)
+		element = append(element, make([]byte, 4)...)
+
+		return protoField(nil, xmlNodeElement, element)
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected map[string]string
+	}{
+		{
+			name:     "Package and version",
+			data:     protoManifest("manifest", protoAttribute("package", "com.example.app"), protoAttribute("versionName", "2.0")),
+			expected: map[string]string{"package": "com.example.app", "versionName": "2.0"},
+		},
+		{
+			name:     "Other wire types are skipped",
+			data:     withOtherWireTypes(),
+			expected: map[string]string{"package": "com.example.app", "versionName": "3.0"},
+		},
+		{
+			name:     "No attributes",
+			data:     protoManifest("manifest"),
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attributes, err := parseProtoManifest(tt.data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, attributes)
+		})
+	}
+}
+
+func TestParseProtoManifestMalformed(t *testing.T) {
+	valid := protoManifest("manifest", protoAttribute("package", "com.example.app"))
+
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{name: "Empty", data: nil, err: "no manifest element"},
+		{name: "Other root element", data: protoManifest("application"), err: "root element is not manifest"},
+		{name: "Truncated key", data: []byte{0x80}, err: errMalformedProto.Error()},
+		{name: "Length past the end", data: []byte{xmlNodeElement<<3 | protoWireBytes, 10, 0}, err: errMalformedProto.Error()},
+		{name: "Truncated varint", data: []byte{1<<3 | protoWireVarint, 0x80}, err: errMalformedProto.Error()},
+		{name: "Truncated fixed64", data: []byte{1<<3 | protoWireFixed64, 0, 0}, err: errMalformedProto.Error()},
+		{name: "Truncated fixed32", data: []byte{1<<3 | protoWireFixed32, 0}, err: errMalformedProto.Error()},
+		{name: "Unsupported wire type", data: []byte{1<<3 | 3}, err: errMalformedProto.Error()},
+		{name: "Malformed element", data: protoField(nil, xmlNodeElement, []byte{0x80}), err: errMalformedProto.Error()},
+		{
+			name: "Malformed attribute",
+			data: protoField(nil, xmlNodeElement, protoField(protoField(nil, xmlElementName, []byte("manifest")), xmlElementAttribute, []byte{0x80})),
+			err:  errMalformedProto.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseProtoManifest(tt.data)
+			require.EqualError(t, err, tt.err)
+		})
+	}
+
+	t.Run("Truncated anywhere", func(t *testing.T) {
+		for n := range len(valid) {
+			_, err := parseProtoManifest(valid[:n])
+			assert.Error(t, err, "truncated to %d bytes", n)
+		}
+	})
+}
@@ -0,0 +1,284 @@
+package appconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nowsecure/nowsecure-ci/internal/redact"
+)
+
+// Sections of the application configuration, as named by the platform API
+var Sections = []string{"dynamic", "static", "integrations"}
+
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Config is the configuration of an application, version-controlled as YAML, e.g.
+//
+//	dynamic:
+//	  login:
+//	    username: ci-user@example.com
+//	    password: ${APP_LOGIN_PASSWORD}
+//	static:
+//	  exclude_libraries: true
+//
+// A file only manages the settings it declares, others are left as they are on the platform. String values may
+// reference environment variables as ${NAME} so credentials don't have to be committed, and values written as
+// [REDACTED], as by ns app config get in CI, keep their current value on the platform
+type Config map[string]any
+
+// Change is a setting whose value on the platform differs from the file
+type Change struct {
+	Path   string `json:"path"`
+	Action string `json:"action"`
+	Old    any    `json:"old,omitempty"`
+	New    any    `json:"new"`
+	// Sensitive is set for the values of secrets, see redact.IsSensitive
+	Sensitive bool `json:"-"`
+}
+
+const (
+	ActionAdd    = "add"
+	ActionChange = "change"
+)
+
+// Load reads a configuration file, expanding environment variable references
+func Load(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid app configuration %s: %w", path, err)
+	}
+
+	for section, value := range raw {
+		if !slices.Contains(Sections, section) {
+			return nil, fmt.Errorf("invalid app configuration %s: unknown section %q, must be one of: %s", path, section, strings.Join(Sections, ", "))
+		}
+		if _, ok := value.(map[string]any); !ok {
+			return nil, fmt.Errorf("invalid app configuration %s: section %q must be a mapping", path, section)
+		}
+	}
+
+	if _, err := expand(raw, ""); err != nil {
+		return nil, fmt.Errorf("invalid app configuration %s: %w", path, err)
+	}
+
+	return normalize(raw)
+}
+
+// FromPlatform keeps the sections of a configuration returned by the platform
+func FromPlatform(config map[string]any) (Config, error) {
+	c := map[string]any{}
+	for _, section := range Sections {
+		if value, ok := config[section]; ok && value != nil {
+			c[section] = value
+		}
+	}
+
+	return normalize(c)
+}
+
+// Diff lists the settings declared by desired whose value differs on current, the configuration on the platform
+func Diff(current, desired Config) ([]Change, error) {
+	resolved, err := resolveMasks(map[string]any(current), map[string]any(desired), "")
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, section := range Sections {
+		if value, ok := resolved.(map[string]any)[section]; ok {
+			diffValue(&changes, section, current[section], value, false)
+		}
+	}
+
+	return changes, nil
+}
+
+// Merge returns the sections declared by desired, updated from current with the settings desired declares. Lists
+// are replaced as a whole
+func Merge(current, desired Config) (Config, error) {
+	resolved, err := resolveMasks(map[string]any(current), map[string]any(desired), "")
+	if err != nil {
+		return nil, err
+	}
+
+	merged := Config{}
+	for section, value := range resolved.(map[string]any) {
+		merged[section] = mergeValue(current[section], value)
+	}
+
+	return merged, nil
+}
+
+// Masked hides the values of a sensitive change
+func (c Change) Masked() Change {
+	if !c.Sensitive {
+		return c
+	}
+
+	if c.Old != nil {
+		c.Old = redact.Mask
+	}
+	c.New = redact.Mask
+	return c
+}
+
+func diffValue(changes *[]Change, path string, current, desired any, sensitive bool) {
+	desiredMap, desiredIsMap := desired.(map[string]any)
+	currentMap, currentIsMap := current.(map[string]any)
+	if desiredIsMap && currentIsMap {
+		for _, key := range slices.Sorted(maps.Keys(desiredMap)) {
+			childSensitive := sensitive || redact.IsSensitive(key) || (key == "value" && hasSensitiveName(desiredMap))
+			childPath := path + "." + key
+			currentValue, ok := currentMap[key]
+			if !ok {
+				*changes = append(*changes, Change{Path: childPath, Action: ActionAdd, New: desiredMap[key], Sensitive: childSensitive})
+				continue
+			}
+			diffValue(changes, childPath, currentValue, desiredMap[key], childSensitive)
+		}
+		return
+	}
+
+	if current == nil && desired != nil {
+		*changes = append(*changes, Change{Path: path, Action: ActionAdd, New: desired, Sensitive: sensitive})
+		return
+	}
+
+	if !reflect.DeepEqual(current, desired) {
+		*changes = append(*changes, Change{Path: path, Action: ActionChange, Old: current, New: desired, Sensitive: sensitive})
+	}
+}
+
+// hasSensitiveName matches {"key": "API_TOKEN", "value": "..."} style entries
+func hasSensitiveName(m map[string]any) bool {
+	for _, field := range []string{"key", "name"} {
+		if name, ok := m[field].(string); ok && redact.IsSensitive(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func mergeValue(current, desired any) any {
+	desiredMap, desiredIsMap := desired.(map[string]any)
+	currentMap, currentIsMap := current.(map[string]any)
+	if !desiredIsMap || !currentIsMap {
+		return desired
+	}
+
+	merged := make(map[string]any, len(currentMap))
+	for key, value := range currentMap {
+		merged[key] = value
+	}
+	for key, value := range desiredMap {
+		merged[key] = mergeValue(currentMap[key], value)
+	}
+
+	return merged
+}
+
+// resolveMasks replaces redacted values of desired with the value at the same place in current
+func resolveMasks(current, desired any, path string) (any, error) {
+	switch t := desired.(type) {
+	case string:
+		if t != redact.Mask {
+			return t, nil
+		}
+		if current == nil {
+			return nil, fmt.Errorf("%s is %s but has no value on the platform to keep", strings.TrimPrefix(path, "."), redact.Mask)
+		}
+		return current, nil
+	case map[string]any:
+		currentMap, _ := current.(map[string]any)
+		resolved := make(map[string]any, len(t))
+		for key, value := range t {
+			var err error
+			if resolved[key], err = resolveMasks(currentMap[key], value, path+"."+key); err != nil {
+				return nil, err
+			}
+		}
+		return resolved, nil
+	case []any:
+		currentList, _ := current.([]any)
+		resolved := make([]any, len(t))
+		for i, value := range t {
+			var currentValue any
+			if i < len(currentList) {
+				currentValue = currentList[i]
+			}
+			var err error
+			if resolved[i], err = resolveMasks(currentValue, value, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return nil, err
+			}
+		}
+		return resolved, nil
+	}
+
+	return desired, nil
+}
+
+// expand replaces ${NAME} references in string values with the value of the environment variable, in place for
+// objects and arrays
+func expand(value any, path string) (any, error) {
+	switch t := value.(type) {
+	case string:
+		var missing []string
+		expanded := envReference.ReplaceAllStringFunc(t, func(reference string) string {
+			name := envReference.FindStringSubmatch(reference)[1]
+			v, ok := os.LookupEnv(name)
+			if !ok {
+				missing = append(missing, name)
+			}
+			return v
+		})
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("%s references unset environment variable %s", strings.TrimPrefix(path, "."), strings.Join(missing, ", "))
+		}
+		return expanded, nil
+	case map[string]any:
+		for key, child := range t {
+			var err error
+			if t[key], err = expand(child, path+"."+key); err != nil {
+				return nil, err
+			}
+		}
+	case []any:
+		for i, child := range t {
+			var err error
+			if t[i], err = expand(child, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return value, nil
+}
+
+// normalize converts values to the types of decoded JSON, so YAML and platform values compare equal
+func normalize(config map[string]any) (Config, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
@@ -0,0 +1,194 @@
+package appconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nowsecure/nowsecure-ci/internal/redact"
+)
+
+func writeFile(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app-config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Environment references are expanded", func(t *testing.T) {
+		t.Setenv("APP_LOGIN_PASSWORD", "hunter2")
+		t.Setenv("APP_REGION", "us-east-1")
+
+		config, err := Load(writeFile(t, `
+dynamic:
+  login:
+    password: ${APP_LOGIN_PASSWORD}
+  environment:
+    - key: REGION
+      value: ${APP_REGION}-${APP_REGION}
+  timeout: 300
+static:
+  exclude_libraries: true
+`))
+		require.NoError(t, err)
+		assert.Equal(t, Config{
+			"dynamic": map[string]any{
+				"login":       map[string]any{"password": "hunter2"},
+				"environment": []any{map[string]any{"key": "REGION", "value": "us-east-1-us-east-1"}},
+				"timeout":     float64(300),
+			},
+			"static": map[string]any{"exclude_libraries": true},
+		}, config)
+	})
+
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{name: "Malformed YAML", data: "dynamic: [", err: "invalid app configuration"},
+		{name: "Unknown section", data: "runtime: {}", err: `unknown section "runtime", must be one of: dynamic, static, integrations`},
+		{name: "Section is not a mapping", data: "static: true", err: `section "static" must be a mapping`},
+		{
+			name: "Unset environment variable",
+			data: "dynamic:\n  environment:\n    - value: ${NS_TEST_UNSET_VARIABLE}",
+			err:  "dynamic.environment[0].value references unset environment variable NS_TEST_UNSET_VARIABLE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeFile(t, tt.data))
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestFromPlatform(t *testing.T) {
+	config, err := FromPlatform(map[string]any{
+		"dynamic": map[string]any{"timeout": 300},
+		"static":  nil,
+		"ref":     "ignored",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Config{"dynamic": map[string]any{"timeout": float64(300)}}, config)
+}
+
+func TestDiff(t *testing.T) {
+	current := Config{
+		"dynamic": map[string]any{
+			"login":       map[string]any{"username": "ci-user", "password": "old-password"},
+			"environment": []any{map[string]any{"key": "API_TOKEN", "value": "abc123"}},
+			"timeout":     float64(300),
+		},
+		"static": map[string]any{"exclude_libraries": false},
+	}
+
+	tests := []struct {
+		name     string
+		desired  Config
+		expected []Change
+	}{
+		{
+			name:    "Undeclared settings are ignored",
+			desired: Config{"dynamic": map[string]any{"timeout": float64(300)}},
+		},
+		{
+			name: "Added and changed settings",
+			desired: Config{
+				"dynamic":      map[string]any{"timeout": float64(600)},
+				"static":       map[string]any{"exclude_libraries": true, "rules": []any{"a"}},
+				"integrations": map[string]any{"jira": map[string]any{"project": "SEC"}},
+			},
+			expected: []Change{
+				{Path: "dynamic.timeout", Action: ActionChange, Old: float64(300), New: float64(600)},
+				{Path: "static.exclude_libraries", Action: ActionChange, Old: false, New: true},
+				{Path: "static.rules", Action: ActionAdd, New: []any{"a"}},
+				{Path: "integrations", Action: ActionAdd, New: map[string]any{"jira": map[string]any{"project": "SEC"}}},
+			},
+		},
+		{
+			name: "Secrets are marked sensitive",
+			desired: Config{"dynamic": map[string]any{
+				"login": map[string]any{"username": "ci-user", "password": "new-password", "api_key": "k3y"},
+			}},
+			expected: []Change{
+				{Path: "dynamic.login.api_key", Action: ActionAdd, New: "k3y", Sensitive: true},
+				{Path: "dynamic.login.password", Action: ActionChange, Old: "old-password", New: "new-password", Sensitive: true},
+			},
+		},
+		{
+			name:    "Redacted values keep their current value",
+			desired: Config{"dynamic": map[string]any{"login": map[string]any{"password": redact.Mask}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := Diff(current, tt.desired)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, changes)
+		})
+	}
+
+	t.Run("Redacted value without a current value", func(t *testing.T) {
+		_, err := Diff(current, Config{"dynamic": map[string]any{"login": map[string]any{"token": redact.Mask}}})
+		require.EqualError(t, err, "dynamic.login.token is [REDACTED] but has no value on the platform to keep")
+	})
+}
+
+func TestMerge(t *testing.T) {
+	current := Config{
+		"dynamic": map[string]any{
+			"login":   map[string]any{"username": "ci-user", "password": "old-password"},
+			"actions": []any{"tap", "swipe"},
+			"timeout": float64(300),
+		},
+		"static": map[string]any{"exclude_libraries": false},
+	}
+
+	merged, err := Merge(current, Config{"dynamic": map[string]any{
+		"login":   map[string]any{"username": "new-user", "password": redact.Mask},
+		"actions": []any{"scroll"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, Config{"dynamic": map[string]any{
+		"login":   map[string]any{"username": "new-user", "password": "old-password"},
+		"actions": []any{"scroll"},
+		"timeout": float64(300),
+	}}, merged)
+}
+
+func TestMasked(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   Change
+		expected Change
+	}{
+		{
+			name:     "Not sensitive",
+			change:   Change{Path: "static.rules", Action: ActionChange, Old: "a", New: "b"},
+			expected: Change{Path: "static.rules", Action: ActionChange, Old: "a", New: "b"},
+		},
+		{
+			name:     "Changed secret",
+			change:   Change{Path: "dynamic.login.password", Action: ActionChange, Old: "a", New: "b", Sensitive: true},
+			expected: Change{Path: "dynamic.login.password", Action: ActionChange, Old: redact.Mask, New: redact.Mask, Sensitive: true},
+		},
+		{
+			name:     "Added secret",
+			change:   Change{Path: "dynamic.login.password", Action: ActionAdd, New: "b", Sensitive: true},
+			expected: Change{Path: "dynamic.login.password", Action: ActionAdd, New: redact.Mask, Sensitive: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.change.Masked())
+		})
+	}
+}
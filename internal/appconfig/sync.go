@@ -0,0 +1,132 @@
+package appconfig
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	types "github.com/oapi-codegen/runtime/types"
+	"github.com/rs/zerolog"
+
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+// AppRef identifies an application on the platform
+type AppRef struct {
+	Platform string `json:"platform"`
+	Package  string `json:"package"`
+}
+
+func (r AppRef) params(group types.UUID) platformapi.AppConfigParams {
+	return platformapi.AppConfigParams{
+		Platform:    r.Platform,
+		PackageName: r.Package,
+		Group:       group,
+	}
+}
+
+// ConfigOutput is the result of comparing a configuration file with the configuration of an application
+type ConfigOutput struct {
+	AppRef
+	Changes []Change `json:"changes"`
+	Applied bool     `json:"applied"`
+}
+
+// SyncParams selects the application and configuration file of Sync
+type SyncParams struct {
+	App   AppRef
+	Group types.UUID
+	// Path is the configuration file to apply
+	Path string
+	// DryRun only lists the changes, without pushing them
+	DryRun bool
+	// HideSensitive masks the old and new values of sensitive settings in the listed changes
+	HideSensitive bool
+}
+
+// Sync compares a configuration file with the configuration of an application, and unless DryRun is set pushes
+// the settings that differ
+func Sync(ctx context.Context, client platformapi.ClientWithResponsesInterface, p SyncParams) (*ConfigOutput, error) {
+	log := zerolog.Ctx(ctx)
+	desired, err := Load(p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := Fetch(ctx, client, p.App, p.Group)
+	if isNotFound(err) {
+		// The first upload of a package, whose configuration is created from the file
+		log.Info().Str("Package", p.App.Package).Msg("App not found on the platform, creating its configuration")
+		current, err = Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := Diff(current, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ConfigOutput{AppRef: p.App, Changes: maskChanges(changes, p.HideSensitive)}
+	if len(changes) == 0 {
+		log.Info().Str("Path", p.Path).Msg("App configuration is up to date")
+		return result, nil
+	}
+
+	for _, change := range changes {
+		// Secrets are masked in the log whether or not they are hidden in the output
+		change = change.Masked()
+		log.Info().Str("Setting", change.Path).Str("Action", change.Action).Any("Old", change.Old).Any("New", change.New).Msg("App configuration differs")
+	}
+
+	if p.DryRun {
+		return result, nil
+	}
+
+	merged, err := Merge(current, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := platformapi.SetAppConfig(ctx, client, p.App.params(p.Group), merged); err != nil {
+		return nil, err
+	}
+	result.Applied = true
+	log.Info().Str("Path", p.Path).Int("Changes", len(changes)).Msg("App configuration updated")
+
+	return result, nil
+}
+
+// Fetch returns the configuration of an application
+func Fetch(ctx context.Context, client platformapi.ClientWithResponsesInterface, ref AppRef, group types.UUID) (Config, error) {
+	current, err := platformapi.GetAppConfig(ctx, client, ref.params(group))
+	if err != nil {
+		return nil, err
+	}
+
+	return FromPlatform(current)
+}
+
+// isNotFound reports whether err is the platform's response for an application it doesn't know
+func isNotFound(err error) bool {
+	var labErr *platformapi.LabRouteError
+	if !errors.As(err, &labErr) || labErr == nil {
+		return false
+	}
+
+	status, statusErr := labErr.StatusCode()
+	return statusErr == nil && status == http.StatusNotFound
+}
+
+func maskChanges(changes []Change, hideSensitive bool) []Change {
+	masked := make([]Change, len(changes))
+	for i, change := range changes {
+		if hideSensitive {
+			change = change.Masked()
+		}
+		masked[i] = change
+	}
+
+	return masked
+}
@@ -0,0 +1,126 @@
+package appconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+var app = AppRef{Platform: "android", Package: "com.example.app"}
+
+func testClient(t *testing.T, doer *platformapi.TestRequestDoer) platformapi.ClientWithResponsesInterface {
+	client, err := platformapi.ClientFromConfig(platformapi.Config{
+		Host:      "https://localhost:8080",
+		UserAgent: "test/1.0",
+		Token:     "token",
+	}, doer)
+	require.NoError(t, err)
+
+	return client
+}
+
+func isConfigRequest(method string) func(req *http.Request) bool {
+	return func(req *http.Request) bool {
+		return req.Method == method && strings.HasSuffix(req.URL.Path, "/app/android/com.example.app/config")
+	}
+}
+
+func jsonResponse(statusCode int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}
+}
+
+func TestSync(t *testing.T) {
+	t.Run("Secrets are masked in the log even when not hidden", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		doer.On("Do", mock.MatchedBy(isConfigRequest(http.MethodGet))).
+			Return(jsonResponse(http.StatusOK, `{"dynamic":{"login":{"username":"ci-user","password":"old-password"}}}`), nil)
+
+		var logs bytes.Buffer
+		ctx := zerolog.New(&logs).WithContext(t.Context())
+		path := writeFile(t, "dynamic:\n  login:\n    username: new-user\n    password: new-password\n")
+
+		result, err := Sync(ctx, testClient(t, doer), SyncParams{App: app, Group: uuid.New(), Path: path, DryRun: true})
+		require.NoError(t, err)
+		assert.False(t, result.Applied)
+		assert.Equal(t, []Change{
+			{Path: "dynamic.login.password", Action: ActionChange, Old: "old-password", New: "new-password", Sensitive: true},
+			{Path: "dynamic.login.username", Action: ActionChange, Old: "ci-user", New: "new-user"},
+		}, result.Changes)
+
+		assert.NotContains(t, logs.String(), "old-password")
+		assert.NotContains(t, logs.String(), "new-password")
+		assert.Contains(t, logs.String(), `"Setting":"dynamic.login.password","Action":"change","Old":"[REDACTED]","New":"[REDACTED]"`)
+		assert.Contains(t, logs.String(), `"Old":"ci-user","New":"new-user"`)
+	})
+
+	t.Run("Changes are pushed", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		doer.On("Do", mock.MatchedBy(isConfigRequest(http.MethodGet))).
+			Return(jsonResponse(http.StatusOK, `{"dynamic":{"timeout":300},"static":{"exclude_libraries":false}}`), nil)
+		var posted map[string]any
+		doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			if !isConfigRequest(http.MethodPost)(req) {
+				return false
+			}
+			body, err := io.ReadAll(req.Body)
+			return err == nil && json.Unmarshal(body, &posted) == nil
+		})).Return(jsonResponse(http.StatusOK, "{}"), nil)
+
+		ctx := zerolog.Nop().WithContext(t.Context())
+		path := writeFile(t, "dynamic:\n  timeout: 600\n")
+
+		result, err := Sync(ctx, testClient(t, doer), SyncParams{App: app, Group: uuid.New(), Path: path, HideSensitive: true})
+		require.NoError(t, err)
+		assert.True(t, result.Applied)
+		assert.Equal(t, map[string]any{"dynamic": map[string]any{"timeout": float64(600)}}, posted)
+	})
+	t.Run("Configuration of an app not on the platform yet is created", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		doer.On("Do", mock.MatchedBy(isConfigRequest(http.MethodGet))).
+			Return(jsonResponse(http.StatusNotFound, `{"status":"404","name":"NotFound","message":"application not found"}`), nil)
+		var posted map[string]any
+		doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			if !isConfigRequest(http.MethodPost)(req) {
+				return false
+			}
+			body, err := io.ReadAll(req.Body)
+			return err == nil && json.Unmarshal(body, &posted) == nil
+		})).Return(jsonResponse(http.StatusOK, "{}"), nil)
+
+		ctx := zerolog.Nop().WithContext(t.Context())
+		path := writeFile(t, "dynamic:\n  timeout: 600\n")
+
+		result, err := Sync(ctx, testClient(t, doer), SyncParams{App: app, Group: uuid.New(), Path: path})
+		require.NoError(t, err)
+		assert.True(t, result.Applied)
+		assert.Equal(t, []Change{{Path: "dynamic", Action: ActionAdd, New: map[string]any{"timeout": float64(600)}}}, result.Changes)
+		assert.Equal(t, map[string]any{"dynamic": map[string]any{"timeout": float64(600)}}, posted)
+	})
+
+	t.Run("Other errors fail", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		doer.On("Do", mock.MatchedBy(isConfigRequest(http.MethodGet))).
+			Return(jsonResponse(http.StatusForbidden, `{"status":"403","name":"Forbidden","message":"no access"}`), nil)
+
+		ctx := zerolog.Nop().WithContext(t.Context())
+		path := writeFile(t, "dynamic:\n  timeout: 600\n")
+
+		_, err := Sync(ctx, testClient(t, doer), SyncParams{App: app, Group: uuid.New(), Path: path})
+		require.EqualError(t, err, "HTTP 403 - Forbidden: no access")
+	})
+}
@@ -0,0 +1,120 @@
+package baseline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+const formatVersion = 1
+
+// Baseline is the set of previously accepted affected findings for an application
+type Baseline struct {
+	Version  int       `json:"version"`
+	Task     float64   `json:"task"`
+	Created  time.Time `json:"created"`
+	Findings []Entry   `json:"findings"`
+}
+
+type Entry struct {
+	Key      string `json:"key"`
+	CheckID  string `json:"check_id"`
+	Title    string `json:"title"`
+	Severity string `json:"severity"`
+}
+
+// Key identifies a finding across assessments by its check ID and analysis type. The platform reports each check
+// once per assessment, so these identify it, while its context holds evidence such as report rows and the PDF view
+// that changes between assessments of the same issue and is left out
+func Key(finding *platformapi.GetAssessmentTaskFindings_2XX_Item) string {
+	return finding.CheckId + ":" + string(finding.AnalysisType)
+}
+
+func New(findings []platformapi.GetAssessmentTaskFindings_2XX_Item, task float64) *Baseline {
+	b := &Baseline{
+		Version:  formatVersion,
+		Task:     task,
+		Created:  time.Now().UTC(),
+		Findings: []Entry{},
+	}
+
+	for i := range findings {
+		finding := &findings[i]
+		if !finding.Affected {
+			continue
+		}
+
+		b.Findings = append(b.Findings, Entry{
+			Key:      Key(finding),
+			CheckID:  finding.CheckId,
+			Title:    finding.Title,
+			Severity: finding.Severity,
+		})
+	}
+
+	return b
+}
+
+func Load(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Baseline{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %w", path, err)
+	}
+
+	if b.Version != formatVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d in %s", b.Version, path)
+	}
+
+	return b, nil
+}
+
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Contains reports whether an affected finding is present in the baseline
+func (b *Baseline) Contains(finding *platformapi.GetAssessmentTaskFindings_2XX_Item) bool {
+	if !finding.Affected {
+		return false
+	}
+
+	key := Key(finding)
+	for _, entry := range b.Findings {
+		if entry.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewFindings drops the affected findings already present in the baseline, leaving only those newly introduced
+func (b *Baseline) NewFindings(findings []platformapi.GetAssessmentTaskFindings_2XX_Item) []platformapi.GetAssessmentTaskFindings_2XX_Item {
+	known := make(map[string]bool, len(b.Findings))
+	for _, entry := range b.Findings {
+		known[entry.Key] = true
+	}
+
+	var filtered []platformapi.GetAssessmentTaskFindings_2XX_Item
+	for i := range findings {
+		if findings[i].Affected && known[Key(&findings[i])] {
+			continue
+		}
+		filtered = append(filtered, findings[i])
+	}
+
+	return filtered
+}
@@ -0,0 +1,97 @@
+package baseline
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+func finding(checkID, analysisType, pdfView string, rows ...map[string]any) platformapi.GetAssessmentTaskFindings_2XX_Item {
+	f := platformapi.GetAssessmentTaskFindings_2XX_Item{
+		Affected:     true,
+		CheckId:      checkID,
+		AnalysisType: platformapi.GetAssessmentTaskFindings2XXAnalysisType(analysisType),
+		Severity:     "high",
+	}
+	f.Context = &struct {
+		Certificate *map[string]interface{}            `json:"certificate,omitempty"`
+		Fields      *map[string]map[string]interface{} `json:"fields,omitempty"`
+		PdfView     *string                            `json:"pdfView,omitempty"`
+		Rows        []map[string]interface{}           `json:"rows,omitempty"`
+	}{PdfView: &pdfView, Rows: rows}
+
+	return f
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  platformapi.GetAssessmentTaskFindings_2XX_Item
+		equal bool
+	}{
+		{
+			name:  "Changed evidence keeps the key",
+			a:     finding("weak_crypto", "static", "page 1", map[string]any{"file": "a.java"}),
+			b:     finding("weak_crypto", "static", "page 7", map[string]any{"file": "b.java"}, map[string]any{"file": "c.java"}),
+			equal: true,
+		},
+		{
+			name: "Different checks differ",
+			a:    finding("weak_crypto", "static", ""),
+			b:    finding("cleartext_traffic", "static", ""),
+		},
+		{
+			name: "Different analysis types differ",
+			a:    finding("weak_crypto", "static", ""),
+			b:    finding("weak_crypto", "dynamic", ""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := Key(&tt.a), Key(&tt.b)
+			assert.Equal(t, tt.a.CheckId+":"+string(tt.a.AnalysisType), a)
+			if tt.equal {
+				assert.Equal(t, a, b)
+			} else {
+				assert.NotEqual(t, a, b)
+			}
+		})
+	}
+}
+
+func TestNewFindings(t *testing.T) {
+	accepted := finding("weak_crypto", "static", "page 1")
+	b := New([]platformapi.GetAssessmentTaskFindings_2XX_Item{accepted, {CheckId: "unaffected"}}, 1)
+	require.Len(t, b.Findings, 1)
+
+	findings := []platformapi.GetAssessmentTaskFindings_2XX_Item{
+		finding("weak_crypto", "static", "page 9"),
+		finding("cleartext_traffic", "static", ""),
+	}
+	assert.True(t, b.Contains(&findings[0]))
+	assert.False(t, b.Contains(&findings[1]))
+
+	fresh := b.NewFindings(findings)
+	require.Len(t, fresh, 1)
+	assert.Equal(t, "cleartext_traffic", fresh[0].CheckId)
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "findings-baseline.json")
+	b := New([]platformapi.GetAssessmentTaskFindings_2XX_Item{finding("weak_crypto", "static", "")}, 12345)
+	require.NoError(t, b.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, b.Findings, loaded.Findings)
+
+	b.Version = 99
+	require.NoError(t, b.Save(path))
+	_, err = Load(path)
+	require.ErrorContains(t, err, "unsupported baseline version 99")
+}
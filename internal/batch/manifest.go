@@ -0,0 +1,182 @@
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nowsecure/nowsecure-ci/internal/gate"
+)
+
+// Manifest lists the apps to assess in a single batch run, e.g.
+//
+//	apps:
+//	  - name: consumer-android
+//	    file: ./android/app/build/outputs/apk/release/app-release.apk
+//	    analysis_type: static
+//	    minimum_score: 70
+//	  - package: com.example.consumer
+//	    platform: ios
+//	    fail_on_severity: high
+//	  - id: aaaaaaaa-1111-bbbb-2222-cccccccccccc
+//	    max_findings: critical=0
+//	  - package: com.example.consumer
+//	    platform: ios
+//	    appstore: true
+//	    app_config: ./ios/nowsecure-config.yaml
+type Manifest struct {
+	Apps []App `yaml:"apps"`
+}
+
+// App is a single assessment of a batch. Exactly one of File, Package or ID selects the app, and the remaining
+// fields override the command line flags for it
+type App struct {
+	Name     string `yaml:"name"`
+	File     string `yaml:"file"`
+	Package  string `yaml:"package"`
+	Platform string `yaml:"platform"`
+	ID       string `yaml:"id"`
+	Appstore bool   `yaml:"appstore"`
+	// AppConfig is applied to the app before it is assessed, see ns app config
+	AppConfig string `yaml:"app_config"`
+
+	AnalysisType   string `yaml:"analysis_type"`
+	MinimumScore   *int   `yaml:"minimum_score"`
+	FailOnSeverity string `yaml:"fail_on_severity"`
+	MaxFindings    string `yaml:"max_findings"`
+
+	AppID        uuid.UUID          `yaml:"-"`
+	SeverityGate *gate.SeverityGate `yaml:"-"`
+}
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Load reads a manifest, resolving file and app config paths relative to the manifest
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+
+	for i := range m.Apps {
+		if m.Apps[i].File != "" && !filepath.IsAbs(m.Apps[i].File) {
+			m.Apps[i].File = filepath.Join(filepath.Dir(path), m.Apps[i].File)
+		}
+		if m.Apps[i].AppConfig != "" && !filepath.IsAbs(m.Apps[i].AppConfig) {
+			m.Apps[i].AppConfig = filepath.Join(filepath.Dir(path), m.Apps[i].AppConfig)
+		}
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// Validate checks every app selects exactly one of file, package or id and names the unnamed ones
+func (m *Manifest) Validate() error {
+	if len(m.Apps) == 0 {
+		return errors.New("no apps listed")
+	}
+
+	var errs []error
+	names := map[string]bool{}
+	for i := range m.Apps {
+		app := &m.Apps[i]
+		if app.Name == "" {
+			app.Name = app.defaultName()
+		}
+		// Names are used as artifact directories
+		app.Name = unsafeName.ReplaceAllString(app.Name, "_")
+
+		if names[app.Name] {
+			errs = append(errs, fmt.Errorf("app %d: duplicate name %q", i+1, app.Name))
+		}
+		names[app.Name] = true
+
+		selectors := 0
+		for _, s := range []string{app.File, app.Package, app.ID} {
+			if s != "" {
+				selectors++
+			}
+		}
+		if selectors != 1 {
+			errs = append(errs, fmt.Errorf("%s: exactly one of file, package or id is required", app.Name))
+		}
+
+		if app.Appstore && app.File != "" {
+			errs = append(errs, fmt.Errorf("%s: appstore applies to package and id entries only", app.Name))
+		}
+
+		if app.Package != "" {
+			app.Platform = strings.ToLower(app.Platform)
+			if app.Platform != "android" && app.Platform != "ios" {
+				errs = append(errs, fmt.Errorf("%s: platform must be one of: android, ios", app.Name))
+			}
+		}
+
+		if app.ID != "" {
+			id, err := uuid.Parse(app.ID)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid id: %w", app.Name, err))
+			}
+			app.AppID = id
+		}
+
+		if app.FailOnSeverity != "" || app.MaxFindings != "" {
+			severityGate, err := parseSeverityGate(app.FailOnSeverity, app.MaxFindings)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", app.Name, err))
+			}
+			app.SeverityGate = severityGate
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (a *App) defaultName() string {
+	switch {
+	case a.File != "":
+		return filepath.Base(a.File)
+	case a.Package != "" && a.Appstore:
+		return a.Package + "-" + a.Platform + "-appstore"
+	case a.Package != "":
+		return a.Package + "-" + a.Platform
+	case a.Appstore:
+		return a.ID + "-appstore"
+	}
+
+	return a.ID
+}
+
+func parseSeverityGate(failOn, maxFindings string) (*gate.SeverityGate, error) {
+	severityGate := &gate.SeverityGate{}
+	if failOn != "" {
+		severity, err := gate.ParseSeverity(failOn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fail_on_severity: %w", err)
+		}
+		severityGate.FailOn = severity
+	}
+
+	limits, err := gate.ParseMaxFindings(maxFindings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid max_findings: %w", err)
+	}
+	severityGate.MaxFindings = limits
+
+	return severityGate, nil
+}
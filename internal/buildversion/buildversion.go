@@ -0,0 +1,33 @@
+package buildversion
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// FromGit describes the commit checked out in dir by its tag when it is tagged, otherwise by the nearest tag plus
+// the commits since and the abbreviated SHA (v1.2.0-3-gabc1234), or only the abbreviated SHA when there are no tags
+func FromGit(ctx context.Context, dir string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "describe", "--tags", "--always")
+	cmd.Dir = dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("cannot derive the version from git: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("cannot derive the version from git: %w", err)
+	}
+
+	version := strings.TrimSpace(string(out))
+	if version == "" {
+		return "", fmt.Errorf("cannot derive the version from git: git describe returned nothing")
+	}
+
+	return version, nil
+}
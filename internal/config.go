@@ -1,8 +1,10 @@
 package internal
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -14,8 +16,13 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/nowsecure/nowsecure-ci/cmd/ns/version"
+	"github.com/nowsecure/nowsecure-ci/internal/baseline"
+	"github.com/nowsecure/nowsecure-ci/internal/buildversion"
+	"github.com/nowsecure/nowsecure-ci/internal/gate"
 	"github.com/nowsecure/nowsecure-ci/internal/output"
 	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+	"github.com/nowsecure/nowsecure-ci/internal/redact"
+	"github.com/nowsecure/nowsecure-ci/internal/suppression"
 )
 
 type BaseConfig struct {
@@ -26,7 +33,14 @@ type BaseConfig struct {
 	LogLevel       zerolog.Level
 	Output         string
 	OutputFormat   output.Formats
+	NoColor        bool
 	UserAgent      string
+	MaxRetries     int
+	RetryMaxWait   time.Duration
+	// HideSensitive asks the platform to hide app configuration secrets and redacts them from the output
+	HideSensitive bool
+	// Redactor masks the token, user-supplied patterns and, with HideSensitive, sensitive fields in logs and output
+	Redactor *redact.Redactor
 }
 
 type RunConfig struct {
@@ -37,7 +51,32 @@ type RunConfig struct {
 	MinimumScore         int
 	Platform             string
 	FindingsArtifactPath string
+	SARIFArtifactPath    string
 	ArtifactsDir         string
+	SeverityGate         gate.SeverityGate
+	Policy               *gate.Policy
+	Baseline             *baseline.Baseline
+	Suppressions         *suppression.File
+	FailOnExpired        bool
+	StateFile            string
+	CancelOnInterrupt    bool
+	SaveReports          []platformapi.ReportFormat
+	SummaryFile          string
+	UploadTimeout        time.Duration
+	UploadProgress       time.Duration
+	PackageName          string
+	ReuseExisting        bool
+	BuildVersion         string
+	Appstore             bool
+	Failfast             bool
+	// AppConfig is a configuration file applied to the app before it is assessed
+	AppConfig string
+	DryRun    bool
+}
+
+// OutputOptions returns the options of the writers of command output
+func (c *BaseConfig) OutputOptions() output.Options {
+	return output.Options{NoColor: c.NoColor, Redactor: c.Redactor}
 }
 
 func NewBaseConfig(v *viper.Viper) (*BaseConfig, error) {
@@ -74,6 +113,16 @@ func NewBaseConfig(v *viper.Viper) (*BaseConfig, error) {
 		switch strings.ToLower(v.GetString("output_format")) {
 		case "json":
 			format = output.JSON
+		case "sarif":
+			format = output.SARIF
+		case "junit":
+			format = output.JUnit
+		case "markdown":
+			format = output.Markdown
+		case "table", "text":
+			format = output.Table
+		case "pretty":
+			format = output.Pretty
 		default:
 			return nil, errors.New("must have valid output format")
 		}
@@ -81,10 +130,33 @@ func NewBaseConfig(v *viper.Viper) (*BaseConfig, error) {
 	platformInfo := fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH)
 	userAgent := strings.TrimSpace(fmt.Sprintf("nowsecure-ci/%s (%s) %s", version.Version(), platformInfo, v.GetString("ci_environment")))
 
+	// Sensitive values are hidden by default in CI, where logs and output files are widely readable
+	hideSensitive := os.Getenv("CI") != "" || v.GetString("ci_environment") != ""
+	if v.IsSet("hide_sensitive_values") {
+		hideSensitive = v.GetBool("hide_sensitive_values")
+	}
+
+	redactor, err := redact.New(hideSensitive, []string{token}, v.GetStringSlice("redact_patterns"))
+	if err != nil {
+		return nil, err
+	}
+
+	maxRetries := v.GetInt("max_retries")
+	if maxRetries < 0 {
+		return nil, errors.New("max_retries must not be negative")
+	}
+
+	retryMaxWait := v.GetDuration("retry_max_wait")
+	if retryMaxWait <= 0 {
+		return nil, errors.New("retry_max_wait must be a positive duration, e.g. 30s")
+	}
+
 	platformClient, err := platformapi.ClientFromConfig(platformapi.Config{
-		Host:      APIHost,
-		UserAgent: userAgent,
-		Token:     token,
+		Host:         APIHost,
+		UserAgent:    userAgent,
+		Token:        token,
+		MaxRetries:   maxRetries,
+		RetryMaxWait: retryMaxWait,
 	}, nil)
 	if err != nil {
 		return nil, err
@@ -97,7 +169,12 @@ func NewBaseConfig(v *viper.Viper) (*BaseConfig, error) {
 		LogLevel:       logLevel,
 		Output:         v.GetString("output"),
 		OutputFormat:   format,
+		NoColor:        v.GetBool("no_color"),
 		UserAgent:      userAgent,
+		MaxRetries:     maxRetries,
+		RetryMaxWait:   retryMaxWait,
+		HideSensitive:  hideSensitive,
+		Redactor:       redactor,
 	}, nil
 }
 
@@ -111,6 +188,102 @@ func NewRunConfig(v *viper.Viper) (*RunConfig, error) {
 		return nil, fmt.Errorf("cannot set save-findings without setting a nonzero poll-for-minutes")
 	}
 
+	if baseConfig.OutputFormat.RequiresFindings() && v.GetInt("poll_for_minutes") <= 0 {
+		return nil, fmt.Errorf("cannot use output-format %s without setting a nonzero poll-for-minutes", v.GetString("output_format"))
+	}
+
+	severityGate := gate.SeverityGate{}
+	if failOn := v.GetString("fail_on_severity"); failOn != "" {
+		severityGate.FailOn, err = gate.ParseSeverity(failOn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fail_on_severity: %w", err)
+		}
+	}
+
+	severityGate.MaxFindings, err = gate.ParseMaxFindings(v.GetString("max_findings"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid max_findings: %w", err)
+	}
+
+	if severityGate.Enabled() && v.GetInt("poll_for_minutes") <= 0 {
+		return nil, fmt.Errorf("cannot set fail-on-severity or max-findings without setting a nonzero poll-for-minutes")
+	}
+
+	var policy *gate.Policy
+	if v.IsSet("policy") {
+		policy = &gate.Policy{}
+		if err := v.UnmarshalKey("policy", policy); err != nil {
+			return nil, fmt.Errorf("invalid policy: %w", err)
+		}
+
+		if err := policy.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid policy: %w", err)
+		}
+
+		if policy.Enabled() && v.GetInt("poll_for_minutes") <= 0 {
+			return nil, fmt.Errorf("cannot evaluate a policy without setting a nonzero poll-for-minutes")
+		}
+	}
+
+	saveReports, err := platformapi.ParseReportFormats(v.GetString("save_report"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid save_report: %w", err)
+	}
+
+	if len(saveReports) > 0 && v.GetInt("poll_for_minutes") <= 0 {
+		return nil, fmt.Errorf("cannot set save-report without setting a nonzero poll-for-minutes")
+	}
+
+	if v.GetString("summary_file") != "" && v.GetInt("poll_for_minutes") <= 0 {
+		return nil, fmt.Errorf("cannot set summary-file without setting a nonzero poll-for-minutes")
+	}
+
+	if v.GetBool("dry_run") && v.GetString("app_config") == "" {
+		return nil, fmt.Errorf("cannot set dry-run without setting app-config")
+	}
+
+	if v.GetBool("dry_run") && baseConfig.OutputFormat.RequiresFindings() {
+		return nil, fmt.Errorf("cannot use output-format %s with dry-run", v.GetString("output_format"))
+	}
+
+	buildVersion := v.GetString("build_version")
+	if v.GetBool("version_from_git") {
+		if buildVersion != "" {
+			return nil, fmt.Errorf("cannot set both version and version-from-git")
+		}
+
+		buildVersion, err = buildversion.FromGit(context.Background(), ".")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Runs that poll gate on the assessment themselves, so they only record it for a later stage when asked to
+	stateFile := v.GetString("state_file")
+	if v.GetInt("poll_for_minutes") > 0 && !v.IsSet("state_file") {
+		stateFile = ""
+	}
+
+	var findingsBaseline *baseline.Baseline
+	if baselinePath := v.GetString("baseline"); baselinePath != "" {
+		findingsBaseline, err = baseline.Load(baselinePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var suppressions *suppression.File
+	if ignoreFile := v.GetString("ignore_file"); ignoreFile != "" {
+		suppressions, err = suppression.Load(ignoreFile)
+		// The default ignore file is optional, an explicitly provided one is not
+		if errors.Is(err, fs.ErrNotExist) && !v.IsSet("ignore_file") {
+			err = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	platform := ""
 
 	if v.IsSet("platform_android") {
@@ -123,6 +296,7 @@ func NewRunConfig(v *viper.Viper) (*RunConfig, error) {
 
 	artifactsDir := v.GetString("artifacts_dir")
 	findingsArtifactPath := ""
+	sarifArtifactPath := ""
 
 	if v.GetBool("save_findings") {
 		if err := os.MkdirAll(artifactsDir, os.ModePerm); err != nil {
@@ -130,12 +304,33 @@ func NewRunConfig(v *viper.Viper) (*RunConfig, error) {
 		}
 
 		findingsArtifactPath = filepath.Join(artifactsDir, "findings.json")
+		sarifArtifactPath = filepath.Join(artifactsDir, "findings.sarif")
 	}
 
 	return &RunConfig{
 		BaseConfig:           *baseConfig,
 		AnalysisType:         v.GetString("analysis_type"),
 		FindingsArtifactPath: findingsArtifactPath,
+		SARIFArtifactPath:    sarifArtifactPath,
+		ArtifactsDir:         artifactsDir,
+		SeverityGate:         severityGate,
+		Policy:               policy,
+		Baseline:             findingsBaseline,
+		Suppressions:         suppressions,
+		FailOnExpired:        v.GetBool("fail_on_expired_suppression"),
+		StateFile:            stateFile,
+		CancelOnInterrupt:    v.GetBool("cancel_on_interrupt"),
+		SaveReports:          saveReports,
+		SummaryFile:          v.GetString("summary_file"),
+		UploadTimeout:        v.GetDuration("upload_timeout"),
+		UploadProgress:       v.GetDuration("upload_progress_interval"),
+		PackageName:          v.GetString("package_name"),
+		ReuseExisting:        v.GetBool("reuse_existing"),
+		BuildVersion:         buildVersion,
+		Appstore:             v.GetBool("appstore"),
+		Failfast:             v.GetBool("failfast"),
+		AppConfig:            v.GetString("app_config"),
+		DryRun:               v.GetBool("dry_run"),
 		PollForMinutes:       v.GetInt("poll_for_minutes"),
 		PollingInterval:      time.Minute,
 		MinimumScore:         v.GetInt("minimum_score"),
@@ -0,0 +1,191 @@
+package gate
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	nserrors "github.com/nowsecure/nowsecure-ci/internal/errors"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+// Policy is the declarative set of pass/fail rules read from the policy section of the config file
+type Policy struct {
+	Rules []Rule `mapstructure:"rules"`
+}
+
+// Rule fails when the score is below MinimumScore, or when more than Max affected findings match
+// every finding criterion set on the rule
+type Rule struct {
+	Name         string   `mapstructure:"name"`
+	MinimumScore *float32 `mapstructure:"minimum_score"`
+	Max          int      `mapstructure:"max"`
+
+	// Finding criteria
+	Severity    string   `mapstructure:"severity"`
+	Cvss        *float32 `mapstructure:"cvss"`
+	CheckIDs    []string `mapstructure:"check_ids"`
+	Categories  []string `mapstructure:"categories"`
+	Regulations []string `mapstructure:"regulations"`
+
+	severity Severity
+}
+
+type RuleResult struct {
+	Name     string   `json:"name"`
+	Passed   bool     `json:"passed"`
+	Reason   string   `json:"reason,omitempty"`
+	Findings []string `json:"findings,omitempty"`
+}
+
+type Evaluation struct {
+	Passed bool         `json:"passed"`
+	Rules  []RuleResult `json:"rules"`
+}
+
+func (p *Policy) Enabled() bool {
+	return p != nil && len(p.Rules) > 0
+}
+
+// Validate checks every rule has at least one criterion and names unnamed rules
+func (p *Policy) Validate() error {
+	var errs []error
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+
+		if rule.Severity != "" {
+			severity, err := ParseSeverity(rule.Severity)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", rule.Name, err))
+			}
+			rule.severity = severity
+		}
+
+		if rule.Max < 0 {
+			errs = append(errs, fmt.Errorf("%s: max must not be negative", rule.Name))
+		}
+
+		if rule.MinimumScore == nil && !rule.hasFindingCriteria() {
+			errs = append(errs, fmt.Errorf("%s: must set minimum_score or at least one of severity, cvss, check_ids, categories, regulations", rule.Name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Evaluate applies every rule to the assessment score and its affected findings
+func (p *Policy) Evaluate(score float32, findings []platformapi.GetAssessmentTaskFindings_2XX_Item) *Evaluation {
+	evaluation := &Evaluation{Passed: true}
+	for i := range p.Rules {
+		result := p.Rules[i].evaluate(score, findings)
+		evaluation.Passed = evaluation.Passed && result.Passed
+		evaluation.Rules = append(evaluation.Rules, result)
+	}
+
+	return evaluation
+}
+
+func (r *Rule) hasFindingCriteria() bool {
+	return r.Severity != "" || r.Cvss != nil || len(r.CheckIDs) > 0 || len(r.Categories) > 0 || len(r.Regulations) > 0
+}
+
+func (r *Rule) evaluate(score float32, findings []platformapi.GetAssessmentTaskFindings_2XX_Item) RuleResult {
+	result := RuleResult{Name: r.Name, Passed: true}
+	var reasons []string
+
+	if r.MinimumScore != nil && score < *r.MinimumScore {
+		result.Passed = false
+		reasons = append(reasons, fmt.Sprintf("score %.2f is less than %.2f", score, *r.MinimumScore))
+	}
+
+	if r.hasFindingCriteria() {
+		for i := range findings {
+			if findings[i].Affected && r.matches(&findings[i]) {
+				result.Findings = append(result.Findings, findings[i].CheckId)
+			}
+		}
+
+		if len(result.Findings) > r.Max {
+			result.Passed = false
+			reasons = append(reasons, fmt.Sprintf("%d matching finding(s), at most %d allowed", len(result.Findings), r.Max))
+		}
+	}
+
+	result.Reason = strings.Join(reasons, "; ")
+	return result
+}
+
+func (r *Rule) matches(finding *platformapi.GetAssessmentTaskFindings_2XX_Item) bool {
+	if r.Severity != "" && SeverityOf(finding) < r.severity {
+		return false
+	}
+
+	if r.Cvss != nil && (finding.Cvss == nil || *finding.Cvss < *r.Cvss) {
+		return false
+	}
+
+	if len(r.CheckIDs) > 0 && !slices.Contains(r.CheckIDs, finding.CheckId) {
+		return false
+	}
+
+	if len(r.Categories) > 0 && (finding.Category == nil || !slices.ContainsFunc(r.Categories, func(c string) bool {
+		return strings.EqualFold(c, *finding.Category)
+	})) {
+		return false
+	}
+
+	if len(r.Regulations) > 0 && !matchesRegulation(r.Regulations, finding) {
+		return false
+	}
+
+	return true
+}
+
+// matchesRegulation matches regulation link IDs by prefix so that e.g. MASVS-STORAGE matches MASVS-STORAGE-1
+func matchesRegulation(regulations []string, finding *platformapi.GetAssessmentTaskFindings_2XX_Item) bool {
+	if finding.Regulations == nil {
+		return false
+	}
+
+	for _, regulation := range *finding.Regulations {
+		for _, link := range regulation.Links {
+			for _, id := range regulations {
+				if strings.HasPrefix(strings.ToUpper(link.Id), strings.ToUpper(id)) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+var _ nserrors.CIError = (*PolicyError)(nil)
+
+type PolicyError struct {
+	Evaluation *Evaluation
+}
+
+func (e *PolicyError) Error() string {
+	var b strings.Builder
+	b.WriteString("policy failed:")
+	for _, rule := range e.Evaluation.Rules {
+		if rule.Passed {
+			continue
+		}
+		fmt.Fprintf(&b, "\n  - %s: %s", rule.Name, rule.Reason)
+		if len(rule.Findings) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(rule.Findings, ", "))
+		}
+	}
+
+	return b.String()
+}
+
+func (e *PolicyError) ExitCode() int {
+	return ExitCode
+}
@@ -0,0 +1,99 @@
+package gate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func score(s float32) *float32 {
+	return &s
+}
+
+func TestPolicyValidate(t *testing.T) {
+	t.Run("Unnamed rules are named", func(t *testing.T) {
+		policy := &Policy{Rules: []Rule{{Name: "no criticals", Severity: "critical"}, {CheckIDs: []string{"a"}}}}
+		require.NoError(t, policy.Validate())
+		assert.Equal(t, "no criticals", policy.Rules[0].Name)
+		assert.Equal(t, "rule 2", policy.Rules[1].Name)
+	})
+
+	tests := []struct {
+		name string
+		rule Rule
+		err  string
+	}{
+		{name: "No criteria", rule: Rule{Max: 1}, err: "rule 1: must set minimum_score or at least one of"},
+		{name: "Invalid severity", rule: Rule{Severity: "severe"}, err: `rule 1: invalid severity "severe"`},
+		{name: "Negative max", rule: Rule{CheckIDs: []string{"a"}, Max: -1}, err: "rule 1: max must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := &Policy{Rules: []Rule{tt.rule}}
+			require.ErrorContains(t, policy.Validate(), tt.err)
+		})
+	}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	assessmentFindings := findings(t, `[
+		{"check_id": "weak_crypto", "severity": "high", "cvss": 7.5, "category": "Cryptography", "affected": true,
+			"regulations": [{"type": "masvs", "links": [{"id": "MASVS-CRYPTO-1"}]}]},
+		{"check_id": "cleartext", "severity": "medium", "cvss": 5.3, "category": "Network", "affected": true,
+			"regulations": [{"type": "masvs", "links": [{"id": "MASVS-NETWORK-1"}]}]},
+		{"check_id": "debuggable", "severity": "critical", "cvss": 9.1, "category": "Code", "affected": false},
+		{"check_id": "backup", "severity": "low", "affected": true}
+	]`)
+
+	tests := []struct {
+		name     string
+		rule     Rule
+		passed   bool
+		findings []string
+		reason   string
+	}{
+		{name: "Severity and above", rule: Rule{Severity: "medium"}, findings: []string{"weak_crypto", "cleartext"}, reason: "2 matching finding(s), at most 0 allowed"},
+		{name: "Unaffected findings are ignored", rule: Rule{Severity: "critical"}, passed: true},
+		{name: "Findings within max", rule: Rule{Severity: "medium", Max: 2}, passed: true, findings: []string{"weak_crypto", "cleartext"}},
+		{name: "CVSS at or above", rule: Rule{Cvss: score(7)}, findings: []string{"weak_crypto"}},
+		{name: "Findings without CVSS don't match", rule: Rule{Cvss: score(0)}, findings: []string{"weak_crypto", "cleartext"}},
+		{name: "Check IDs", rule: Rule{CheckIDs: []string{"backup", "debuggable"}}, findings: []string{"backup"}},
+		{name: "Categories ignore case", rule: Rule{Categories: []string{"network"}}, findings: []string{"cleartext"}},
+		{name: "Regulations match by prefix", rule: Rule{Regulations: []string{"masvs-crypto"}}, findings: []string{"weak_crypto"}},
+		{name: "Every criterion must match", rule: Rule{Severity: "medium", Categories: []string{"Network", "Cryptography"}, Cvss: score(6)}, findings: []string{"weak_crypto"}},
+		{name: "Minimum score", rule: Rule{MinimumScore: score(80)}, reason: "score 72.50 is less than 80.00"},
+		{name: "Minimum score met", rule: Rule{MinimumScore: score(70)}, passed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := &Policy{Rules: []Rule{tt.rule}}
+			require.NoError(t, policy.Validate())
+
+			evaluation := policy.Evaluate(72.5, assessmentFindings)
+			assert.Equal(t, tt.passed, evaluation.Passed)
+			require.Len(t, evaluation.Rules, 1)
+			assert.Equal(t, tt.passed, evaluation.Rules[0].Passed)
+			assert.Equal(t, tt.findings, evaluation.Rules[0].Findings)
+			if tt.reason != "" {
+				assert.Equal(t, tt.reason, evaluation.Rules[0].Reason)
+			}
+		})
+	}
+
+	t.Run("Error lists the failed rules", func(t *testing.T) {
+		policy := &Policy{Rules: []Rule{
+			{Name: "no high", Severity: "high"},
+			{Name: "score", MinimumScore: score(50)},
+		}}
+		require.NoError(t, policy.Validate())
+
+		evaluation := policy.Evaluate(72.5, assessmentFindings)
+		assert.False(t, evaluation.Passed)
+		err := &PolicyError{Evaluation: evaluation}
+		assert.Equal(t, "policy failed:\n  - no high: 1 matching finding(s), at most 0 allowed [weak_crypto]", err.Error())
+		assert.Equal(t, ExitCode, err.ExitCode())
+	})
+}
@@ -0,0 +1,142 @@
+package gate
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+type Severity int
+
+const (
+	Unknown Severity = iota
+	Info
+	Warn
+	Low
+	Medium
+	High
+	Critical
+)
+
+var severityNames = map[Severity]string{
+	Info:     "info",
+	Warn:     "warn",
+	Low:      "low",
+	Medium:   "medium",
+	High:     "high",
+	Critical: "critical",
+}
+
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+func ParseSeverity(s string) (Severity, error) {
+	for severity, name := range severityNames {
+		if strings.EqualFold(strings.TrimSpace(s), name) {
+			return severity, nil
+		}
+	}
+
+	return Unknown, fmt.Errorf("invalid severity %q, must be one of: info, warn, low, medium, high, critical", s)
+}
+
+// SeverityOf returns the severity of a finding, findings with unrecognized severities are Unknown
+func SeverityOf(finding *platformapi.GetAssessmentTaskFindings_2XX_Item) Severity {
+	severity, err := ParseSeverity(finding.Severity)
+	if err != nil {
+		return Unknown
+	}
+
+	return severity
+}
+
+// ParseMaxFindings parses a comma separated list of severity=count pairs, e.g. "critical=0,high=3"
+func ParseMaxFindings(s string) (map[Severity]int, error) {
+	limits := map[Severity]int{}
+	if strings.TrimSpace(s) == "" {
+		return limits, nil
+	}
+
+	for pair := range strings.SplitSeq(s, ",") {
+		name, count, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid max findings %q, expected severity=count", pair)
+		}
+
+		severity, err := ParseSeverity(name)
+		if err != nil {
+			return nil, err
+		}
+
+		limit, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid max findings count %q for %s", count, severity)
+		}
+
+		limits[severity] = limit
+	}
+
+	return limits, nil
+}
+
+// SeverityGate fails an assessment based on the severities of its affected findings
+type SeverityGate struct {
+	// FailOn fails on any finding at or above this severity, Unknown (the zero value) disables the check
+	FailOn      Severity
+	MaxFindings map[Severity]int
+}
+
+func (g SeverityGate) Enabled() bool {
+	return g.FailOn != Unknown || len(g.MaxFindings) > 0
+}
+
+// Evaluate returns the affected findings which violate the gate
+func (g SeverityGate) Evaluate(findings []platformapi.GetAssessmentTaskFindings_2XX_Item) []Violation {
+	var violations []Violation
+	bySeverity := map[Severity][]*platformapi.GetAssessmentTaskFindings_2XX_Item{}
+
+	for i := range findings {
+		finding := &findings[i]
+		if !finding.Affected {
+			continue
+		}
+
+		severity := SeverityOf(finding)
+		bySeverity[severity] = append(bySeverity[severity], finding)
+
+		if g.FailOn != Unknown && severity >= g.FailOn {
+			violations = append(violations, Violation{
+				Finding: finding,
+				Rule:    fmt.Sprintf("fail-on-severity %s", g.FailOn),
+			})
+		}
+	}
+
+	severities := slices.Sorted(maps.Keys(g.MaxFindings))
+	slices.Reverse(severities)
+
+	for _, severity := range severities {
+		limit := g.MaxFindings[severity]
+		if len(bySeverity[severity]) <= limit {
+			continue
+		}
+
+		for _, finding := range bySeverity[severity] {
+			violations = append(violations, Violation{
+				Finding: finding,
+				Rule:    fmt.Sprintf("max-findings %s=%d (found %d)", severity, limit, len(bySeverity[severity])),
+			})
+		}
+	}
+
+	return violations
+}
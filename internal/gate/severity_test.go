@@ -0,0 +1,125 @@
+package gate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+// findings decodes findings as returned by the platform
+func findings(t *testing.T, data string) []platformapi.GetAssessmentTaskFindings_2XX_Item {
+	t.Helper()
+	var f []platformapi.GetAssessmentTaskFindings_2XX_Item
+	require.NoError(t, json.Unmarshal([]byte(data), &f))
+	return f
+}
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Severity
+		err      string
+	}{
+		{input: "critical", expected: Critical},
+		{input: " High ", expected: High},
+		{input: "WARN", expected: Warn},
+		{input: "severe", err: `invalid severity "severe"`},
+		{input: "", err: `invalid severity ""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			severity, err := ParseSeverity(tt.input)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, severity)
+		})
+	}
+}
+
+func TestParseMaxFindings(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[Severity]int
+		err      string
+	}{
+		{name: "Empty", input: " ", expected: map[Severity]int{}},
+		{name: "Pairs", input: "critical=0, high = 3", expected: map[Severity]int{Critical: 0, High: 3}},
+		{name: "Missing count", input: "critical", err: `invalid max findings "critical"`},
+		{name: "Invalid severity", input: "severe=1", err: `invalid severity "severe"`},
+		{name: "Negative count", input: "high=-1", err: `invalid max findings count "-1" for high`},
+		{name: "Non numeric count", input: "high=many", err: `invalid max findings count "many" for high`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits, err := ParseMaxFindings(tt.input)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, limits)
+		})
+	}
+}
+
+func TestSeverityGate(t *testing.T) {
+	assessmentFindings := findings(t, `[
+		{"check_id": "a", "severity": "critical", "affected": true},
+		{"check_id": "b", "severity": "high", "affected": true},
+		{"check_id": "c", "severity": "high", "affected": true},
+		{"check_id": "d", "severity": "medium", "affected": true},
+		{"check_id": "e", "severity": "critical", "affected": false},
+		{"check_id": "f", "severity": "bogus", "affected": true}
+	]`)
+
+	tests := []struct {
+		name     string
+		gate     SeverityGate
+		expected []string
+	}{
+		{name: "No limits", gate: SeverityGate{MaxFindings: map[Severity]int{}}},
+		{name: "Fail on severity and above", gate: SeverityGate{FailOn: High}, expected: []string{"a", "b", "c"}},
+		{name: "Max findings within limit", gate: SeverityGate{MaxFindings: map[Severity]int{High: 2, Medium: 1}}},
+		{
+			name:     "Max findings over limit",
+			gate:     SeverityGate{MaxFindings: map[Severity]int{Medium: 0, High: 1}},
+			expected: []string{"b", "c", "d"},
+		},
+		{name: "Unknown severities are only limited by max findings", gate: SeverityGate{MaxFindings: map[Severity]int{Unknown: 0}}, expected: []string{"f"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var checkIDs []string
+			for _, violation := range tt.gate.Evaluate(assessmentFindings) {
+				checkIDs = append(checkIDs, violation.Finding.CheckId)
+			}
+			assert.Equal(t, tt.expected, checkIDs)
+		})
+	}
+
+	t.Run("Zero value is disabled", func(t *testing.T) {
+		assert.False(t, SeverityGate{}.Enabled())
+		assert.True(t, SeverityGate{MaxFindings: map[Severity]int{High: 0}}.Enabled())
+	})
+
+	t.Run("Violations name the rule", func(t *testing.T) {
+		violations := SeverityGate{MaxFindings: map[Severity]int{High: 1}}.Evaluate(assessmentFindings)
+		require.Len(t, violations, 2)
+		assert.Equal(t, "max-findings high=1 (found 2)", violations[0].Rule)
+
+		err := &Error{Violations: violations}
+		assert.Equal(t, ExitCode, err.ExitCode())
+		assert.Contains(t, err.Error(), "2 finding(s) violate the gate:")
+	})
+}
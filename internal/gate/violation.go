@@ -0,0 +1,42 @@
+package gate
+
+import (
+	"fmt"
+	"strings"
+
+	nserrors "github.com/nowsecure/nowsecure-ci/internal/errors"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+// ExitCode is returned when an assessment completes but its findings violate a gate
+const ExitCode = 2
+
+type Violation struct {
+	Finding *platformapi.GetAssessmentTaskFindings_2XX_Item
+	Rule    string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("[%s] %s: %s (%s)", v.Finding.Severity, v.Finding.CheckId, v.Finding.Title, v.Rule)
+}
+
+var _ nserrors.CIError = (*Error)(nil)
+
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d finding(s) violate the gate:", len(e.Violations))
+	for _, v := range e.Violations {
+		b.WriteString("\n  - ")
+		b.WriteString(v.String())
+	}
+
+	return b.String()
+}
+
+func (e *Error) ExitCode() int {
+	return ExitCode
+}
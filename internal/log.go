@@ -4,24 +4,36 @@ import (
 	"os"
 
 	"github.com/rs/zerolog"
+
+	"github.com/nowsecure/nowsecure-ci/internal/redact"
 )
 
 // Implements zerlog.LevelWriter
-type ConsoleLevelWriter struct{}
+type ConsoleLevelWriter struct {
+	// Redactor masks secrets in the written events
+	Redactor *redact.Redactor
+}
 
 func (l ConsoleLevelWriter) Write(p []byte) (n int, err error) {
-	return os.Stdout.Write(p)
+	if _, err := os.Stdout.Write(l.Redactor.JSON(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
 }
 
 func (l ConsoleLevelWriter) WriteLevel(level zerolog.Level, p []byte) (n int, err error) {
+	out := os.Stderr
 	if level <= zerolog.WarnLevel {
-		return zerolog.ConsoleWriter{Out: os.Stdout}.Write(p)
+		out = os.Stdout
+	}
+	if _, err := (zerolog.ConsoleWriter{Out: out}).Write(l.Redactor.JSON(p)); err != nil {
+		return 0, err
 	}
-	return zerolog.ConsoleWriter{Out: os.Stderr}.Write(p)
+	return len(p), nil
 }
 
-func LoggerWithLevel(level zerolog.Level) zerolog.Logger {
-	return zerolog.New(ConsoleLevelWriter{}).
+func LoggerWithLevel(level zerolog.Level, redactor *redact.Redactor) zerolog.Logger {
+	return zerolog.New(ConsoleLevelWriter{Redactor: redactor}).
 		With().
 		Timestamp().
 		Logger().
@@ -0,0 +1,39 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	types "github.com/oapi-codegen/runtime/types"
+	"github.com/rs/zerolog"
+
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+// SaveReports downloads each report format of an assessment into dir as report.<format>
+func SaveReports(ctx context.Context, client platformapi.ClientWithResponsesInterface, ref types.UUID, formats []platformapi.ReportFormat, dir string) error {
+	log := zerolog.Ctx(ctx)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, format := range formats {
+		body, err := platformapi.DownloadReport(ctx, client, ref, format)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		path := filepath.Join(dir, "report."+string(format))
+		if err := os.WriteFile(path, body, 0o644); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		log.Info().Str("Path", path).Msg("Saved report")
+	}
+
+	return errors.Join(errs...)
+}
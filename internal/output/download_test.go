@@ -0,0 +1,80 @@
+package output
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+func getTestClient(t *testing.T, doer *platformapi.TestRequestDoer) platformapi.ClientWithResponsesInterface {
+	client, err := platformapi.ClientFromConfig(platformapi.Config{
+		Host:      "https://localhost:8080",
+		UserAgent: "test/1.0",
+		Token:     "token",
+	}, doer)
+	require.NoError(t, err)
+
+	return client
+}
+
+func useReport(doer *platformapi.TestRequestDoer, ref uuid.UUID, format string, statusCode int, body string) {
+	doer.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/assessment/"+ref.String()+"/report."+format)
+	})).Return(&http.Response{
+		StatusCode: statusCode,
+		Status:     http.StatusText(statusCode),
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Header:     http.Header{"Content-Type": []string{"application/octet-stream"}},
+	}, nil)
+}
+
+func TestSaveReports(t *testing.T) {
+	ref := uuid.New()
+
+	t.Run("Every requested format is written to the directory", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		dir := filepath.Join(t.TempDir(), "reports")
+
+		useReport(doer, ref, "pdf", http.StatusOK, "%PDF-1.7")
+		useReport(doer, ref, "json", http.StatusOK, `{"score":85}`)
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err := SaveReports(ctx, getTestClient(t, doer), ref, []platformapi.ReportFormat{platformapi.ReportPDF, platformapi.ReportJSON}, dir)
+		require.NoError(t, err)
+
+		pdf, err := os.ReadFile(filepath.Join(dir, "report.pdf"))
+		require.NoError(t, err)
+		assert.Equal(t, "%PDF-1.7", string(pdf))
+
+		report, err := os.ReadFile(filepath.Join(dir, "report.json"))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"score":85}`, string(report))
+
+		assert.NoFileExists(t, filepath.Join(dir, "report.html"))
+	})
+
+	t.Run("A failed download does not prevent the other formats", func(t *testing.T) {
+		doer := &platformapi.TestRequestDoer{}
+		dir := t.TempDir()
+
+		useReport(doer, ref, "html", http.StatusNotFound, "not found")
+		useReport(doer, ref, "pdf", http.StatusOK, "%PDF-1.7")
+
+		ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+		err := SaveReports(ctx, getTestClient(t, doer), ref, []platformapi.ReportFormat{platformapi.ReportHTML, platformapi.ReportPDF}, dir)
+		require.ErrorContains(t, err, "failed to download html report")
+		assert.FileExists(t, filepath.Join(dir, "report.pdf"))
+	})
+}
@@ -0,0 +1,184 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+	"github.com/nowsecure/nowsecure-ci/internal/suppression"
+)
+
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []JUnitTestCase `xml:"testcase"`
+}
+
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Skipped   *JUnitSkipped `xml:"skipped,omitempty"`
+}
+
+type JUnitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+// NewJUnit renders the minimum score and severity gates and every check of a report as JUnit test cases.
+// Affected findings fail, baselined and suppressed findings are skipped, unaffected checks pass, and suites are
+// grouped by category
+func NewJUnit(r *Report) *JUnitTestSuites {
+	minimumScore := JUnitTestCase{
+		Name:      "minimum-score",
+		ClassName: "nowsecure.gate",
+	}
+	if !r.Passed() {
+		minimumScore.Failure = &JUnitFailure{
+			Message: fmt.Sprintf("the score %.2f is less than the required minimum %d", r.Score, r.MinimumScore),
+			Type:    "gate",
+		}
+	}
+
+	suites := []JUnitTestSuite{{Name: "gate"}}
+	suites[0].add(minimumScore)
+
+	if r.SeverityGate.Enabled() {
+		severity := JUnitTestCase{
+			Name:      "severity",
+			ClassName: "nowsecure.gate",
+		}
+		if len(r.Violations) > 0 {
+			violations := make([]string, len(r.Violations))
+			for i, v := range r.Violations {
+				violations[i] = v.String()
+			}
+			severity.Failure = &JUnitFailure{
+				Message: fmt.Sprintf("%d finding(s) violate the gate", len(r.Violations)),
+				Type:    "gate",
+				Content: strings.Join(violations, "\n"),
+			}
+		}
+		suites[0].add(severity)
+	}
+
+	if r.Policy != nil {
+		policy := JUnitTestSuite{Name: "policy"}
+		for _, rule := range r.Policy.Rules {
+			testCase := JUnitTestCase{Name: rule.Name, ClassName: "nowsecure.policy"}
+			if !rule.Passed {
+				testCase.Failure = &JUnitFailure{
+					Message: rule.Reason,
+					Type:    "policy",
+					Content: strings.Join(rule.Findings, "\n"),
+				}
+			}
+			policy.add(testCase)
+		}
+		suites = append(suites, policy)
+	}
+
+	suiteIndex := map[string]int{}
+	for i := range r.Findings {
+		finding := &r.Findings[i]
+		name := junitSuiteName(finding)
+
+		index, ok := suiteIndex[name]
+		if !ok {
+			index = len(suites)
+			suiteIndex[name] = index
+			suites = append(suites, JUnitTestSuite{Name: name})
+		}
+
+		suites[index].add(junitTestCase(name, finding, r.Baselined(finding), r.Suppression(finding)))
+	}
+
+	result := &JUnitTestSuites{
+		Name:   fmt.Sprintf("NowSecure %s %s", r.Platform, r.Package),
+		Suites: suites,
+	}
+	for i := range suites {
+		result.Tests += suites[i].Tests
+		result.Failures += suites[i].Failures
+		result.Skipped += suites[i].Skipped
+	}
+
+	return result
+}
+
+func (s *JUnitTestSuite) add(testCase JUnitTestCase) {
+	s.Tests++
+	if testCase.Failure != nil {
+		s.Failures++
+	}
+	if testCase.Skipped != nil {
+		s.Skipped++
+	}
+	s.TestCases = append(s.TestCases, testCase)
+}
+
+func junitSuiteName(finding *platformapi.GetAssessmentTaskFindings_2XX_Item) string {
+	if finding.Category != nil && *finding.Category != "" {
+		return *finding.Category
+	}
+
+	return string(finding.AnalysisType)
+}
+
+func junitTestCase(suite string, finding *platformapi.GetAssessmentTaskFindings_2XX_Item, baselined bool, suppressed *suppression.Suppression) JUnitTestCase {
+	testCase := JUnitTestCase{
+		Name:      fmt.Sprintf("%s: %s", finding.CheckId, finding.Title),
+		ClassName: "nowsecure." + suite,
+	}
+
+	if !finding.Affected {
+		return testCase
+	}
+
+	if suppressed != nil {
+		testCase.Skipped = &JUnitSkipped{
+			Message: fmt.Sprintf("suppressed until %s: %s", suppressed.Expires, suppressed.Justification),
+		}
+		return testCase
+	}
+
+	if baselined {
+		testCase.Skipped = &JUnitSkipped{Message: "accepted in the baseline"}
+		return testCase
+	}
+
+	var content strings.Builder
+	if finding.Description != nil {
+		content.WriteString(*finding.Description)
+	}
+	if finding.Recommendations.Developer != nil {
+		content.WriteString("\n\nRecommendation:\n")
+		content.WriteString(*finding.Recommendations.Developer)
+	}
+
+	testCase.Failure = &JUnitFailure{
+		Message: fmt.Sprintf("[%s] %s", finding.Severity, finding.Title),
+		Type:    finding.Severity,
+		Content: content.String(),
+	}
+
+	return testCase
+}
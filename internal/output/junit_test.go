@@ -0,0 +1,79 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nowsecure/nowsecure-ci/internal/gate"
+)
+
+func TestNewJUnit(t *testing.T) {
+	t.Run("Findings", func(t *testing.T) {
+		junit := NewJUnit(testReport(t))
+		assert.Equal(t, "NowSecure android com.example.app", junit.Name)
+		assert.Equal(t, 5, junit.Tests)
+		assert.Equal(t, 1, junit.Failures)
+		assert.Equal(t, 2, junit.Skipped)
+
+		var names []string
+		for _, suite := range junit.Suites {
+			names = append(names, suite.Name)
+		}
+		assert.Equal(t, []string{"gate", "Cryptography", "static", "Code", "dynamic"}, names)
+
+		gateSuite := junit.Suites[0]
+		require.Len(t, gateSuite.TestCases, 1)
+		assert.Equal(t, JUnitTestCase{Name: "minimum-score", ClassName: "nowsecure.gate"}, gateSuite.TestCases[0])
+
+		assert.Equal(t, JUnitTestCase{
+			Name:      "weak_crypto: Weak | cryptography",
+			ClassName: "nowsecure.Cryptography",
+			Failure:   &JUnitFailure{Message: "[high] Weak | cryptography", Type: "high", Content: "Uses DES\n\nRecommendation:\nUse AES"},
+		}, junit.Suites[1].TestCases[0])
+		assert.Equal(t, &JUnitSkipped{Message: "suppressed until 2030-01-31: Backups are encrypted"}, junit.Suites[2].TestCases[0].Skipped)
+		assert.Equal(t, &JUnitSkipped{Message: "accepted in the baseline"}, junit.Suites[3].TestCases[0].Skipped)
+		// A suppression doesn't skip an unaffected check, which passes
+		assert.Equal(t, JUnitTestCase{Name: "cleartext: Cleartext traffic", ClassName: "nowsecure.dynamic"}, junit.Suites[4].TestCases[0])
+	})
+
+	t.Run("Failed gates", func(t *testing.T) {
+		r := testReport(t)
+		r.MinimumScore = 80
+		r.SeverityGate = gate.SeverityGate{FailOn: gate.High}
+		r.Violations = r.SeverityGate.Evaluate(r.Findings)
+		r.Policy = &gate.Evaluation{Rules: []gate.RuleResult{
+			{Name: "no high", Reason: "1 matching finding(s), at most 0 allowed", Findings: []string{"weak_crypto"}},
+			{Name: "score", Passed: true},
+		}}
+
+		junit := NewJUnit(r)
+		assert.Equal(t, 4, junit.Failures)
+
+		gateSuite := junit.Suites[0]
+		assert.Equal(t, 2, gateSuite.Failures)
+		assert.Equal(t, &JUnitFailure{Message: "the score 72.50 is less than the required minimum 80", Type: "gate"}, gateSuite.TestCases[0].Failure)
+		assert.Equal(t, "severity", gateSuite.TestCases[1].Name)
+		assert.Equal(t, &JUnitFailure{
+			Message: "1 finding(s) violate the gate",
+			Type:    "gate",
+			Content: "[high] weak_crypto: Weak | cryptography (fail-on-severity high)",
+		}, gateSuite.TestCases[1].Failure)
+
+		policySuite := junit.Suites[1]
+		assert.Equal(t, "policy", policySuite.Name)
+		assert.Equal(t, 2, policySuite.Tests)
+		assert.Equal(t, &JUnitFailure{Message: "1 matching finding(s), at most 0 allowed", Type: "policy", Content: "weak_crypto"}, policySuite.TestCases[0].Failure)
+		assert.Nil(t, policySuite.TestCases[1].Failure)
+	})
+
+	t.Run("Passed severity gate", func(t *testing.T) {
+		r := testReport(t)
+		r.SeverityGate = gate.SeverityGate{FailOn: gate.Critical}
+
+		gateSuite := NewJUnit(r).Suites[0]
+		require.Len(t, gateSuite.TestCases, 2)
+		assert.Equal(t, JUnitTestCase{Name: "severity", ClassName: "nowsecure.gate"}, gateSuite.TestCases[1])
+	})
+}
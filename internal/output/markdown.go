@@ -0,0 +1,128 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nowsecure/nowsecure-ci/internal/gate"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+	"github.com/nowsecure/nowsecure-ci/internal/redact"
+)
+
+var markdownSeverities = []gate.Severity{gate.Critical, gate.High, gate.Medium, gate.Low, gate.Warn, gate.Info, gate.Unknown}
+
+// NewMarkdown renders a report as a summary suitable for pull request comments and CI job summaries:
+// the gate result, score, assessment link, and the affected findings grouped by severity
+func NewMarkdown(r *Report) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "## NowSecure assessment of %s (%s)\n\n", r.Package, r.Platform)
+
+	if r.GateError == nil {
+		b.WriteString("**Result:** :white_check_mark: Passed\n\n")
+	} else {
+		b.WriteString("**Result:** :x: Failed\n\n")
+	}
+
+	fmt.Fprintf(&b, "**Score:** %.2f (minimum %d)\n\n", r.Score, r.MinimumScore)
+
+	if r.Version != "" {
+		fmt.Fprintf(&b, "**Version:** %s\n\n", r.Version)
+	}
+
+	if r.URL != "" {
+		fmt.Fprintf(&b, "**Assessment:** [View on NowSecure Platform](%s)\n\n", r.URL)
+	}
+
+	if r.GateError != nil {
+		fmt.Fprintf(&b, "```\n%s\n```\n\n", r.GateError.Error())
+	}
+
+	var affected []platformapi.GetAssessmentTaskFindings_2XX_Item
+	var suppressed []platformapi.GetAssessmentTaskFindings_2XX_Item
+	for _, finding := range r.AffectedFindings() {
+		if r.Suppression(&finding) != nil {
+			suppressed = append(suppressed, finding)
+		} else {
+			affected = append(affected, finding)
+		}
+	}
+
+	fmt.Fprintf(&b, "### Affected findings (%d)\n\n", len(affected))
+	if len(affected) == 0 {
+		b.WriteString("No affected findings.\n\n")
+	}
+
+	for _, severity := range markdownSeverities {
+		var group []platformapi.GetAssessmentTaskFindings_2XX_Item
+		for _, finding := range affected {
+			if gate.SeverityOf(&finding) == severity {
+				group = append(group, finding)
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "#### %s (%d)\n\n", strings.ToUpper(severity.String()[:1])+severity.String()[1:], len(group))
+		b.WriteString("| Check | Title | CVSS | Reference |\n")
+		b.WriteString("| --- | --- | --- | --- |\n")
+		for _, finding := range group {
+			cvss := "-"
+			if finding.Cvss != nil {
+				cvss = fmt.Sprintf("%.1f", *finding.Cvss)
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", finding.CheckId, markdownEscape(finding.Title), cvss, markdownReference(&finding))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(suppressed) > 0 {
+		fmt.Fprintf(&b, "### Suppressed findings (%d)\n\n", len(suppressed))
+		b.WriteString("| Check | Title | Justification | Expires |\n")
+		b.WriteString("| --- | --- | --- | --- |\n")
+		for _, finding := range suppressed {
+			s := r.Suppression(&finding)
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", finding.CheckId, markdownEscape(finding.Title), markdownEscape(s.Justification), s.Expires)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// markdownReference links the first regulation reference of a finding
+func markdownReference(finding *platformapi.GetAssessmentTaskFindings_2XX_Item) string {
+	if finding.Regulations == nil {
+		return "-"
+	}
+
+	for _, regulation := range *finding.Regulations {
+		for _, link := range regulation.Links {
+			if link.Url != nil {
+				return fmt.Sprintf("[%s](%s)", markdownEscape(link.Id), *link.Url)
+			}
+		}
+	}
+
+	return "-"
+}
+
+// markdownEscape keeps text from breaking out of a table cell
+func markdownEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// AppendMarkdown appends the markdown summary of a report to a file, e.g. the job summary file of a CI run
+func AppendMarkdown(path string, r *Report, redactor *redact.Redactor) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(redactor.String(NewMarkdown(r)))
+	return err
+}
@@ -0,0 +1,78 @@
+package output
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nowsecure/nowsecure-ci/internal/redact"
+)
+
+func TestNewMarkdown(t *testing.T) {
+	t.Run("Passed", func(t *testing.T) {
+		r := testReport(t)
+		r.Suppressions["allow_backup"].Justification = "Encrypted | see\nSEC-123"
+
+		assert.Equal(t, "## NowSecure assessment of com.example.app (android)\n\n"+
+			"**Result:** :white_check_mark: Passed\n\n"+
+			"**Score:** 72.50 (minimum 70)\n\n"+
+			"**Assessment:** [View on NowSecure Platform](https://app.nowsecure.com/app/1/assessment/2)\n\n"+
+			"### Affected findings (2)\n\n"+
+			"#### High (1)\n\n"+
+			"| Check | Title | CVSS | Reference |\n"+
+			"| --- | --- | --- | --- |\n"+
+			"| `weak_crypto` | Weak \\| cryptography | 7.5 | [MASVS-CRYPTO-1](https://mas.owasp.org/crypto) |\n\n"+
+			"#### Low (1)\n\n"+
+			"| Check | Title | CVSS | Reference |\n"+
+			"| --- | --- | --- | --- |\n"+
+			"| `debuggable` | Debuggable | - | - |\n\n"+
+			"### Suppressed findings (1)\n\n"+
+			"| Check | Title | Justification | Expires |\n"+
+			"| --- | --- | --- | --- |\n"+
+			"| `allow_backup` | Backups allowed | Encrypted \\| see SEC-123 | 2030-01-31 |\n\n",
+			NewMarkdown(r))
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		r := testReport(t)
+		r.Version = "1.2.3"
+		r.GateError = errors.New("1 finding(s) violate the gate")
+
+		markdown := NewMarkdown(r)
+		assert.Contains(t, markdown, "**Result:** :x: Failed\n\n")
+		assert.Contains(t, markdown, "**Version:** 1.2.3\n\n")
+		assert.Contains(t, markdown, "```\n1 finding(s) violate the gate\n```\n\n")
+	})
+
+	t.Run("No affected findings", func(t *testing.T) {
+		markdown := NewMarkdown(&Report{Package: "com.example.app", Platform: "ios"})
+		assert.Contains(t, markdown, "### Affected findings (0)\n\nNo affected findings.\n\n")
+		assert.NotContains(t, markdown, "Suppressed findings")
+		assert.NotContains(t, markdown, "**Assessment:**")
+	})
+}
+
+func TestAppendMarkdown(t *testing.T) {
+	r := testReport(t)
+	r.URL = "https://app.nowsecure.com/app/1/assessment/2?token=secret-token"
+	redactor, err := redact.New(false, []string{"secret-token"}, nil)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "summary.md")
+	require.NoError(t, os.WriteFile(path, []byte("# Job summary\n\n"), 0o600))
+	require.NoError(t, AppendMarkdown(path, r, redactor))
+	require.NoError(t, AppendMarkdown(path, r, redactor))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	summary := string(data)
+	assert.Contains(t, summary, "# Job summary\n\n## NowSecure assessment of com.example.app")
+	assert.Equal(t, 2, strings.Count(summary, "## NowSecure assessment"))
+	assert.Contains(t, summary, "?token=[REDACTED]")
+	assert.NotContains(t, summary, "secret-token")
+}
@@ -1,10 +1,14 @@
 package output
 
 import (
+	"bytes"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"os"
+
+	"github.com/nowsecure/nowsecure-ci/internal/redact"
 )
 
 type Formats int
@@ -12,33 +16,104 @@ type Formats int
 const (
 	JSON Formats = iota
 	Pretty
+	SARIF
+	JUnit
+	Markdown
+	Table
 )
 
+// RequiresFindings reports whether the format can only be rendered from a completed assessment's findings
+func (f Formats) RequiresFindings() bool {
+	return f == SARIF || f == JUnit || f == Markdown
+}
+
+func (f Formats) String() string {
+	switch f {
+	case JSON:
+		return "json"
+	case Pretty:
+		return "pretty"
+	case SARIF:
+		return "sarif"
+	case JUnit:
+		return "junit"
+	case Markdown:
+		return "markdown"
+	case Table:
+		return "table"
+	}
+
+	return "unknown"
+}
+
+// Extension returns the file extension conventionally used for the format
+func (f Formats) Extension() string {
+	switch f {
+	case SARIF:
+		return "sarif"
+	case JUnit:
+		return "xml"
+	case Markdown:
+		return "md"
+	case Table:
+		return "txt"
+	}
+
+	return "json"
+}
+
+// Options configure how a CLIWriter renders its output
+type Options struct {
+	// NoColor disables colored table output even when writing to a terminal
+	NoColor bool
+	// Redactor masks secrets in the written output
+	Redactor *redact.Redactor
+}
+
 type CLIWriter struct {
 	writer io.Writer
 	format Formats
+	// color and width only apply to table output on a terminal
+	color    bool
+	width    int
+	redactor *redact.Redactor
 }
 
-func New(outputPath string, format Formats) (*CLIWriter, error) {
-	var w io.Writer
-
+func New(outputPath string, format Formats, opts Options) (*CLIWriter, error) {
 	if outputPath != "" {
 		file, err := os.Create(outputPath)
 		if err != nil {
 			return nil, err
 		}
-		w = file
-	} else {
-		w = os.Stdout
+		return &CLIWriter{
+			writer:   file,
+			format:   format,
+			redactor: opts.Redactor,
+		}, nil
 	}
 
+	color, width := terminal(os.Stdout, opts.NoColor)
 	return &CLIWriter{
-		writer: w,
-		format: format,
+		writer:   os.Stdout,
+		format:   format,
+		color:    color,
+		width:    width,
+		redactor: opts.Redactor,
 	}, nil
 }
 
 func (o *CLIWriter) Write(data any) error {
+	data, err := o.redactor.Value(data)
+	if err != nil {
+		return err
+	}
+
+	return o.WriteRaw(data)
+}
+
+// WriteRaw writes data without masking secrets, for files read back by the CLI such as the state and baseline
+// files, which a redact pattern could otherwise corrupt
+func (o *CLIWriter) WriteRaw(data any) error {
 	switch o.format {
 	case JSON:
 		return json.NewEncoder(o.writer).Encode(data)
@@ -46,11 +121,57 @@ func (o *CLIWriter) Write(data any) error {
 		enc := json.NewEncoder(o.writer)
 		enc.SetIndent("", "  ")
 		return enc.Encode(data)
+	case Table:
+		text, err := NewText(data)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(o.writer, text)
+		return err
+	case SARIF, JUnit, Markdown:
+		return fmt.Errorf("%s output requires a completed assessment", o.format)
 	default:
 		return fmt.Errorf("unknown format option provided")
 	}
 }
 
+func (o *CLIWriter) WriteReport(r *Report) error {
+	switch o.format {
+	case SARIF:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(NewSARIF(r)); err != nil {
+			return err
+		}
+		return o.writeString(buf.String())
+	case JUnit:
+		buf := bytes.NewBufferString(xml.Header)
+		enc := xml.NewEncoder(buf)
+		enc.Indent("", "  ")
+		if err := enc.Encode(NewJUnit(r)); err != nil {
+			return err
+		}
+		return o.writeString(buf.String() + "\n")
+	case Markdown:
+		return o.writeString(NewMarkdown(r))
+	case Table:
+		return o.writeString(NewTable(r, o.width, o.color))
+	default:
+		data, err := r.jsonData()
+		if err != nil {
+			return err
+		}
+		return o.Write(data)
+	}
+}
+
+// writeString writes rendered output, masking secrets in the text as a whole so none is split across writes
+func (o *CLIWriter) writeString(s string) error {
+	_, err := io.WriteString(o.writer, o.redactor.String(s))
+	return err
+}
+
 func (o *CLIWriter) Close() error {
 	if o.writer == os.Stdout || o.writer == os.Stderr {
 		return nil
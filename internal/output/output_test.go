@@ -0,0 +1,79 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nowsecure/nowsecure-ci/internal/redact"
+)
+
+func TestCLIWriter(t *testing.T) {
+	redactor, err := redact.New(true, []string{"secret-token"}, nil)
+	require.NoError(t, err)
+	data := map[string]any{"url": "https://host?t=secret-token", "password": "hunter2", "task": 12345}
+
+	tests := []struct {
+		name     string
+		format   Formats
+		write    func(w *CLIWriter) error
+		expected string
+	}{
+		{
+			name:     "Written data is redacted",
+			format:   JSON,
+			write:    func(w *CLIWriter) error { return w.Write(data) },
+			expected: `{"password":"[REDACTED]","task":12345,"url":"https://host?t=[REDACTED]"}` + "\n",
+		},
+		{
+			name:     "Raw data is written as is",
+			format:   JSON,
+			write:    func(w *CLIWriter) error { return w.WriteRaw(data) },
+			expected: `{"password":"hunter2","task":12345,"url":"https://host?t=secret-token"}` + "\n",
+		},
+		{
+			name:     "Pretty",
+			format:   Pretty,
+			write:    func(w *CLIWriter) error { return w.Write(map[string]any{"task": 12345}) },
+			expected: "{\n  \"task\": 12345\n}\n",
+		},
+		{
+			name:     "Rendered reports are redacted",
+			format:   Markdown,
+			write:    func(w *CLIWriter) error { return w.WriteReport(&Report{Package: "com.example.secret-token"}) },
+			expected: NewMarkdown(&Report{Package: "com.example.[REDACTED]"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "output")
+			w, err := New(path, tt.format, Options{Redactor: redactor})
+			require.NoError(t, err)
+			require.NoError(t, tt.write(w))
+			require.NoError(t, w.Close())
+
+			written, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(written))
+		})
+	}
+
+	t.Run("Report formats require a completed assessment", func(t *testing.T) {
+		for _, format := range []Formats{SARIF, JUnit, Markdown} {
+			assert.True(t, format.RequiresFindings())
+			w, err := New(filepath.Join(t.TempDir(), "output"), format, Options{})
+			require.NoError(t, err)
+			require.EqualError(t, w.Write(data), format.String()+" output requires a completed assessment")
+			require.NoError(t, w.Close())
+		}
+	})
+
+	t.Run("Missing output directory", func(t *testing.T) {
+		_, err := New(filepath.Join(t.TempDir(), "missing", "output"), JSON, Options{})
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
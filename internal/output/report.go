@@ -0,0 +1,149 @@
+package output
+
+import (
+	"encoding/json"
+
+	"github.com/nowsecure/nowsecure-ci/internal/baseline"
+	"github.com/nowsecure/nowsecure-ci/internal/gate"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+	"github.com/nowsecure/nowsecure-ci/internal/suppression"
+)
+
+// Report describes a completed assessment along with its findings
+type Report struct {
+	// Assessment is written as-is by the JSON based formats
+	Assessment   any
+	Package      string
+	Platform     string
+	Task         float64
+	Score        float32
+	MinimumScore int
+	Findings     []platformapi.GetAssessmentTaskFindings_2XX_Item
+	// URL links to the assessment on the NowSecure Platform
+	URL string
+	// Version is the build version set on upload, empty when the platform read it from the binary
+	Version string
+	// Policy is the result of evaluating the configured policy, nil when no policy is configured
+	Policy *gate.Evaluation
+	// Suppressions maps check IDs to the active suppression waiving them
+	Suppressions map[string]*suppression.Suppression
+	// Baseline holds the accepted findings excluded from gating, nil when no baseline is configured
+	Baseline *baseline.Baseline
+	// SeverityGate is the fail-on-severity and max-findings gate, Violations the gated findings violating it
+	SeverityGate gate.SeverityGate
+	Violations   []gate.Violation
+	// GateError is the first gate the assessment failed, nil when it passed every gate
+	GateError error
+}
+
+// Passed reports whether the assessment score meets the minimum score
+func (r *Report) Passed() bool {
+	return r.Score >= float32(r.MinimumScore)
+}
+
+// AffectedFindings returns the findings which affect the assessed application
+func (r *Report) AffectedFindings() []platformapi.GetAssessmentTaskFindings_2XX_Item {
+	var affected []platformapi.GetAssessmentTaskFindings_2XX_Item
+	for i := range r.Findings {
+		if r.Findings[i].Affected {
+			affected = append(affected, r.Findings[i])
+		}
+	}
+
+	return affected
+}
+
+// Suppression returns the active suppression waiving an affected finding, or nil
+func (r *Report) Suppression(finding *platformapi.GetAssessmentTaskFindings_2XX_Item) *suppression.Suppression {
+	if !finding.Affected {
+		return nil
+	}
+
+	return r.Suppressions[finding.CheckId]
+}
+
+// Baselined reports whether an affected finding was accepted in the baseline
+func (r *Report) Baselined(finding *platformapi.GetAssessmentTaskFindings_2XX_Item) bool {
+	return r.Baseline != nil && r.Baseline.Contains(finding)
+}
+
+// SuppressedFindings returns the active suppressions which waived at least one affected finding
+func (r *Report) SuppressedFindings() []*suppression.Suppression {
+	var suppressed []*suppression.Suppression
+	seen := map[string]bool{}
+	for i := range r.Findings {
+		if s := r.Suppression(&r.Findings[i]); s != nil && !seen[s.CheckID] {
+			seen[s.CheckID] = true
+			suppressed = append(suppressed, s)
+		}
+	}
+
+	return suppressed
+}
+
+// AnnotatedAffectedFindings returns the affected findings with a "suppression" key added to the suppressed ones
+func (r *Report) AnnotatedAffectedFindings() ([]any, error) {
+	affected := r.AffectedFindings()
+	annotated := make([]any, 0, len(affected))
+	for i := range affected {
+		s := r.Suppression(&affected[i])
+		if s == nil {
+			annotated = append(annotated, affected[i])
+			continue
+		}
+
+		data, err := withKey(affected[i], "suppression", s)
+		if err != nil {
+			return nil, err
+		}
+		annotated = append(annotated, data)
+	}
+
+	return annotated, nil
+}
+
+// jsonData returns the assessment with the build version, policy evaluation and suppressed findings added
+// under the "version", "policy" and "suppressed" keys
+func (r *Report) jsonData() (any, error) {
+	data := r.Assessment
+	var err error
+
+	if r.Version != "" {
+		data, err = withKey(data, "version", r.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Policy != nil {
+		data, err = withKey(data, "policy", r.Policy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if suppressed := r.SuppressedFindings(); len(suppressed) > 0 {
+		data, err = withKey(data, "suppressed", suppressed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// withKey adds a key to the JSON object encoding of data
+func withKey(data any, key string, value any) (map[string]json.RawMessage, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	object := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &object); err != nil {
+		return nil, err
+	}
+
+	object[key], err = json.Marshal(value)
+	return object, err
+}
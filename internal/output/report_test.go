@@ -0,0 +1,102 @@
+package output
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nowsecure/nowsecure-ci/internal/baseline"
+	"github.com/nowsecure/nowsecure-ci/internal/gate"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+	"github.com/nowsecure/nowsecure-ci/internal/suppression"
+)
+
+// testReport returns a report of an assessment with a high, a suppressed, a baselined and an unaffected finding
+func testReport(t *testing.T) *Report {
+	t.Helper()
+	var findings []platformapi.GetAssessmentTaskFindings_2XX_Item
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"check_id": "weak_crypto", "title": "Weak | cryptography", "severity": "high", "affected": true,
+			"analysis_type": "static", "category": "Cryptography", "cvss": 7.5, "cvss_vector": "CVSS:3.1/AV:N",
+			"description": "Uses DES", "recommendations": {"developer": "Use AES"},
+			"regulations": [{"type": "masvs", "links": [{"id": "MASVS-CRYPTO-1", "url": "https://mas.owasp.org/crypto"}]}]},
+		{"check_id": "allow_backup", "title": "Backups allowed", "severity": "medium", "affected": true, "analysis_type": "static"},
+		{"check_id": "debuggable", "title": "Debuggable", "severity": "low", "affected": true, "analysis_type": "static", "category": "Code"},
+		{"check_id": "cleartext", "title": "Cleartext traffic", "severity": "critical", "affected": false, "analysis_type": "dynamic"}
+	]`), &findings))
+
+	return &Report{
+		Assessment:   map[string]any{"task": 12345, "score": 72.5},
+		Package:      "com.example.app",
+		Platform:     "android",
+		Task:         12345,
+		Score:        72.5,
+		MinimumScore: 70,
+		Findings:     findings,
+		URL:          "https://app.nowsecure.com/app/1/assessment/2",
+		Suppressions: map[string]*suppression.Suppression{
+			"allow_backup": {CheckID: "allow_backup", Justification: "Backups are encrypted", Expires: "2030-01-31"},
+			"cleartext":    {CheckID: "cleartext", Justification: "Not affected", Expires: "2030-01-31"},
+		},
+		Baseline: baseline.New(findings[2:3], 12000),
+	}
+}
+
+func TestReport(t *testing.T) {
+	r := testReport(t)
+
+	t.Run("Passed", func(t *testing.T) {
+		assert.True(t, r.Passed())
+		failed := *r
+		failed.MinimumScore = 80
+		assert.False(t, failed.Passed())
+	})
+
+	t.Run("Suppressions only apply to affected findings", func(t *testing.T) {
+		assert.NotNil(t, r.Suppression(&r.Findings[1]))
+		assert.Nil(t, r.Suppression(&r.Findings[0]))
+		assert.Nil(t, r.Suppression(&r.Findings[3]))
+
+		suppressed := r.SuppressedFindings()
+		require.Len(t, suppressed, 1)
+		assert.Equal(t, "allow_backup", suppressed[0].CheckID)
+	})
+
+	t.Run("Baselined", func(t *testing.T) {
+		assert.True(t, r.Baselined(&r.Findings[2]))
+		assert.False(t, r.Baselined(&r.Findings[0]))
+
+		withoutBaseline := *r
+		withoutBaseline.Baseline = nil
+		assert.False(t, withoutBaseline.Baselined(&r.Findings[2]))
+	})
+
+	t.Run("Annotated affected findings", func(t *testing.T) {
+		annotated, err := r.AnnotatedAffectedFindings()
+		require.NoError(t, err)
+		require.Len(t, annotated, 3)
+		assert.IsType(t, platformapi.GetAssessmentTaskFindings_2XX_Item{}, annotated[0])
+		assert.JSONEq(t, `{"check_id":"allow_backup","justification":"Backups are encrypted","expires":"2030-01-31"}`,
+			string(annotated[1].(map[string]json.RawMessage)["suppression"]))
+	})
+
+	t.Run("JSON data", func(t *testing.T) {
+		withPolicy := *r
+		withPolicy.Version = "1.2.3"
+		withPolicy.Policy = &gate.Evaluation{Passed: true, Rules: []gate.RuleResult{{Name: "no criticals", Passed: true}}}
+
+		data, err := withPolicy.jsonData()
+		require.NoError(t, err)
+		body, err := json.Marshal(data)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"task": 12345,
+			"score": 72.5,
+			"version": "1.2.3",
+			"policy": {"passed": true, "rules": [{"name": "no criticals", "passed": true}]},
+			"suppressed": [{"check_id": "allow_backup", "justification": "Backups are encrypted", "expires": "2030-01-31"}]
+		}`, string(body))
+	})
+}
@@ -0,0 +1,218 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nowsecure/nowsecure-ci/cmd/ns/version"
+	"github.com/nowsecure/nowsecure-ci/internal/gate"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool       SARIFTool           `json:"tool"`
+	Results    []SARIFResult       `json:"results"`
+	Properties *SARIFRunProperties `json:"properties,omitempty"`
+}
+
+type SARIFRunProperties struct {
+	Policy *gate.Evaluation `json:"policy,omitempty"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+type SARIFRule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name,omitempty"`
+	ShortDescription     SARIFMessage           `json:"shortDescription"`
+	FullDescription      *SARIFMessage          `json:"fullDescription,omitempty"`
+	Help                 *SARIFMessage          `json:"help,omitempty"`
+	HelpURI              string                 `json:"helpUri,omitempty"`
+	DefaultConfiguration SARIFRuleConfiguration `json:"defaultConfiguration"`
+	Properties           SARIFRuleProperties    `json:"properties"`
+}
+
+type SARIFRuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+type SARIFRuleProperties struct {
+	Tags             []string `json:"tags,omitempty"`
+	Severity         string   `json:"severity,omitempty"`
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+	CvssVector       string   `json:"cvssVector,omitempty"`
+	Regulations      []string `json:"regulations,omitempty"`
+}
+
+type SARIFMessage struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+type SARIFResult struct {
+	RuleID       string             `json:"ruleId"`
+	RuleIndex    int                `json:"ruleIndex"`
+	Level        string             `json:"level"`
+	Message      SARIFMessage       `json:"message"`
+	Locations    []SARIFLocation    `json:"locations"`
+	Suppressions []SARIFSuppression `json:"suppressions,omitempty"`
+}
+
+type SARIFSuppression struct {
+	Kind          string `json:"kind"`
+	Status        string `json:"status"`
+	Justification string `json:"justification"`
+}
+
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+}
+
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// NewSARIF maps the affected findings of a report onto a single SARIF run, one rule per check ID
+func NewSARIF(r *Report) *SARIFLog {
+	driver := SARIFDriver{
+		Name:           "NowSecure",
+		Version:        version.Version(),
+		InformationURI: "https://www.nowsecure.com",
+		Rules:          []SARIFRule{},
+	}
+	results := []SARIFResult{}
+	ruleIndex := map[string]int{}
+
+	// Code scanning dashboards require a location, the application itself is the closest thing we have
+	location := SARIFLocation{
+		PhysicalLocation: SARIFPhysicalLocation{
+			ArtifactLocation: SARIFArtifactLocation{URI: r.Package},
+		},
+	}
+
+	findings := r.AffectedFindings()
+	for i := range findings {
+		finding := &findings[i]
+		level := sarifLevel(finding.Severity)
+
+		index, ok := ruleIndex[finding.CheckId]
+		if !ok {
+			index = len(driver.Rules)
+			ruleIndex[finding.CheckId] = index
+			driver.Rules = append(driver.Rules, sarifRule(finding, level))
+		}
+
+		result := SARIFResult{
+			RuleID:    finding.CheckId,
+			RuleIndex: index,
+			Level:     level,
+			Message:   SARIFMessage{Text: finding.Title},
+			Locations: []SARIFLocation{location},
+		}
+		if s := r.Suppression(finding); s != nil {
+			result.Suppressions = []SARIFSuppression{{
+				Kind:          "external",
+				Status:        "accepted",
+				Justification: fmt.Sprintf("%s (expires %s)", s.Justification, s.Expires),
+			}}
+		}
+		results = append(results, result)
+	}
+
+	run := SARIFRun{
+		Tool:    SARIFTool{Driver: driver},
+		Results: results,
+	}
+	if r.Policy != nil {
+		run.Properties = &SARIFRunProperties{Policy: r.Policy}
+	}
+
+	return &SARIFLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []SARIFRun{run},
+	}
+}
+
+func sarifRule(finding *platformapi.GetAssessmentTaskFindings_2XX_Item, level string) SARIFRule {
+	rule := SARIFRule{
+		ID:                   finding.CheckId,
+		Name:                 finding.CheckId,
+		ShortDescription:     SARIFMessage{Text: finding.Title},
+		DefaultConfiguration: SARIFRuleConfiguration{Level: level},
+		Properties: SARIFRuleProperties{
+			Tags:     []string{"security"},
+			Severity: finding.Severity,
+		},
+	}
+
+	if finding.Description != nil {
+		rule.FullDescription = &SARIFMessage{Text: *finding.Description, Markdown: *finding.Description}
+	}
+
+	if finding.Recommendations.Developer != nil {
+		rule.Help = &SARIFMessage{Text: *finding.Recommendations.Developer, Markdown: *finding.Recommendations.Developer}
+	}
+
+	if finding.Cvss != nil {
+		rule.Properties.SecuritySeverity = fmt.Sprintf("%.1f", *finding.Cvss)
+	}
+
+	if finding.CvssVector != nil {
+		rule.Properties.CvssVector = *finding.CvssVector
+	}
+
+	if finding.Category != nil {
+		rule.Properties.Tags = append(rule.Properties.Tags, *finding.Category)
+	}
+
+	if finding.Regulations != nil {
+		for _, regulation := range *finding.Regulations {
+			for _, link := range regulation.Links {
+				rule.Properties.Regulations = append(rule.Properties.Regulations, fmt.Sprintf("%s:%s", regulation.Type, link.Id))
+				if rule.HelpURI == "" && link.Url != nil {
+					rule.HelpURI = *link.Url
+				}
+			}
+		}
+	}
+
+	return rule
+}
+
+func sarifLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "high":
+		return "error"
+	case "medium":
+		return "warning"
+	case "low", "warn", "info":
+		return "note"
+	}
+
+	return "none"
+}
@@ -0,0 +1,94 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nowsecure/nowsecure-ci/internal/gate"
+)
+
+func TestSARIFLevel(t *testing.T) {
+	tests := map[string]string{
+		"critical": "error",
+		"High":     "error",
+		"medium":   "warning",
+		"low":      "note",
+		"warn":     "note",
+		"info":     "note",
+		"":         "none",
+		"bogus":    "none",
+	}
+
+	for severity, level := range tests {
+		t.Run(severity, func(t *testing.T) {
+			assert.Equal(t, level, sarifLevel(severity))
+		})
+	}
+}
+
+func TestNewSARIF(t *testing.T) {
+	r := testReport(t)
+	// A second result of the same check shares its rule
+	r.Findings = append(r.Findings, r.Findings[0])
+
+	log := NewSARIF(r)
+	assert.Equal(t, sarifSchema, log.Schema)
+	assert.Equal(t, sarifVersion, log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Nil(t, run.Properties)
+
+	assert.Equal(t, "NowSecure", run.Tool.Driver.Name)
+	require.Len(t, run.Tool.Driver.Rules, 3)
+	assert.Equal(t, SARIFRule{
+		ID:                   "weak_crypto",
+		Name:                 "weak_crypto",
+		ShortDescription:     SARIFMessage{Text: "Weak | cryptography"},
+		FullDescription:      &SARIFMessage{Text: "Uses DES", Markdown: "Uses DES"},
+		Help:                 &SARIFMessage{Text: "Use AES", Markdown: "Use AES"},
+		HelpURI:              "https://mas.owasp.org/crypto",
+		DefaultConfiguration: SARIFRuleConfiguration{Level: "error"},
+		Properties: SARIFRuleProperties{
+			Tags:             []string{"security", "Cryptography"},
+			Severity:         "high",
+			SecuritySeverity: "7.5",
+			CvssVector:       "CVSS:3.1/AV:N",
+			Regulations:      []string{"masvs:MASVS-CRYPTO-1"},
+		},
+	}, run.Tool.Driver.Rules[0])
+	assert.Equal(t, []string{"security"}, run.Tool.Driver.Rules[1].Properties.Tags)
+
+	var ruleIDs []string
+	var ruleIndexes []int
+	for _, result := range run.Results {
+		ruleIDs = append(ruleIDs, result.RuleID)
+		ruleIndexes = append(ruleIndexes, result.RuleIndex)
+		assert.Equal(t, "com.example.app", result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	}
+	// The unaffected finding has no result
+	assert.Equal(t, []string{"weak_crypto", "allow_backup", "debuggable", "weak_crypto"}, ruleIDs)
+	assert.Equal(t, []int{0, 1, 2, 0}, ruleIndexes)
+
+	assert.Empty(t, run.Results[0].Suppressions)
+	assert.Equal(t, []SARIFSuppression{{
+		Kind:          "external",
+		Status:        "accepted",
+		Justification: "Backups are encrypted (expires 2030-01-31)",
+	}}, run.Results[1].Suppressions)
+	assert.Equal(t, "note", run.Results[2].Level)
+
+	t.Run("Policy is a run property", func(t *testing.T) {
+		r.Policy = &gate.Evaluation{Passed: false, Rules: []gate.RuleResult{{Name: "no high", Reason: "1 matching finding(s)"}}}
+		run := NewSARIF(r).Runs[0]
+		require.NotNil(t, run.Properties)
+		assert.Same(t, r.Policy, run.Properties.Policy)
+	})
+
+	t.Run("No findings", func(t *testing.T) {
+		run := NewSARIF(&Report{Package: "com.example.app"}).Runs[0]
+		assert.NotNil(t, run.Results)
+		assert.NotNil(t, run.Tool.Driver.Rules)
+	})
+}
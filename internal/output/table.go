@@ -0,0 +1,209 @@
+package output
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+
+	"github.com/nowsecure/nowsecure-ci/internal/gate"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+
+	minTitleWidth = 20
+)
+
+var severityColors = map[gate.Severity]string{
+	gate.Critical: ansiBold + ansiRed,
+	gate.High:     ansiRed,
+	gate.Medium:   ansiYellow,
+	gate.Low:      ansiCyan,
+}
+
+// terminal returns whether table output to f should be colored and the width to fit it to, 0 meaning unlimited
+func terminal(f *os.File, noColor bool) (bool, int) {
+	if !isatty.IsTerminal(f.Fd()) && !isatty.IsCygwinTerminal(f.Fd()) {
+		return false, 0
+	}
+
+	width := terminalWidth(f)
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		width = columns
+	}
+
+	_, noColorEnv := os.LookupEnv("NO_COLOR")
+	return !noColor && !noColorEnv, width
+}
+
+type tableStyle struct {
+	color bool
+}
+
+func (s tableStyle) paint(text, code string) string {
+	if !s.color || code == "" {
+		return text
+	}
+
+	return code + text + ansiReset
+}
+
+// NewTable renders a report for a terminal: a summary header followed by the affected findings sorted by severity.
+// Titles are truncated to fit width unless it is 0
+func NewTable(r *Report, width int, color bool) string {
+	style := tableStyle{color: color}
+	var b strings.Builder
+
+	result := style.paint("PASSED", ansiBold+ansiGreen)
+	if r.GateError != nil {
+		result = style.paint("FAILED", ansiBold+ansiRed)
+	}
+
+	header := [][2]string{
+		{"App", fmt.Sprintf("%s (%s)", r.Package, r.Platform)},
+	}
+	if r.Version != "" {
+		header = append(header, [2]string{"Version", r.Version})
+	}
+	header = append(header, [][2]string{
+		{"Task", strconv.FormatFloat(r.Task, 'f', -1, 64)},
+		{"Status", "completed"},
+		{"Score", fmt.Sprintf("%.2f (minimum %d)", r.Score, r.MinimumScore)},
+		{"Result", result},
+	}...)
+	if r.URL != "" {
+		header = append(header, [2]string{"URL", r.URL})
+	}
+	for _, line := range header {
+		fmt.Fprintf(&b, "%-8s %s\n", line[0]+":", line[1])
+	}
+
+	if r.GateError != nil {
+		fmt.Fprintf(&b, "\n%s\n", style.paint(r.GateError.Error(), ansiRed))
+	}
+
+	findings := r.AffectedFindings()
+	slices.SortStableFunc(findings, func(a, b platformapi.GetAssessmentTaskFindings_2XX_Item) int {
+		return cmp.Or(
+			cmp.Compare(gate.SeverityOf(&b), gate.SeverityOf(&a)),
+			cmp.Compare(cvssOf(&b), cvssOf(&a)),
+			strings.Compare(a.CheckId, b.CheckId),
+		)
+	})
+
+	fmt.Fprintf(&b, "\nAffected findings: %d\n", len(findings))
+	if len(findings) == 0 {
+		return b.String()
+	}
+
+	rows := [][4]string{{"SEVERITY", "CVSS", "CHECK", "TITLE"}}
+	for i := range findings {
+		finding := &findings[i]
+		cvss := "-"
+		if finding.Cvss != nil {
+			cvss = fmt.Sprintf("%.1f", *finding.Cvss)
+		}
+
+		title := strings.Join(strings.Fields(finding.Title), " ")
+		if s := r.Suppression(finding); s != nil {
+			title += fmt.Sprintf(" (suppressed until %s)", s.Expires)
+		}
+		rows = append(rows, [4]string{gate.SeverityOf(finding).String(), cvss, finding.CheckId, title})
+	}
+
+	var widths [3]int
+	for _, row := range rows {
+		for i := range widths {
+			widths[i] = max(widths[i], len(row[i]))
+		}
+	}
+
+	titleWidth := 0
+	if width > 0 {
+		titleWidth = max(width-widths[0]-widths[1]-widths[2]-6, minTitleWidth)
+	}
+
+	b.WriteString("\n")
+	for i, row := range rows {
+		title := truncate(row[3], titleWidth)
+		line := fmt.Sprintf("%-*s  %-*s  %-*s  %s", widths[0], row[0], widths[1], row[1], widths[2], row[2], title)
+		switch {
+		case i == 0:
+			line = style.paint(line, ansiBold)
+		case r.Suppression(&findings[i-1]) != nil:
+			line = style.paint(line, ansiDim)
+		default:
+			// Pad before painting so that escape codes don't throw off the alignment
+			severity := fmt.Sprintf("%-*s", widths[0], row[0])
+			line = style.paint(severity, severityColors[gate.SeverityOf(&findings[i-1])]) + line[len(severity):]
+		}
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+
+	return b.String()
+}
+
+// NewText renders arbitrary command output as aligned key: value lines, falling back to indented JSON
+// for anything but an object
+func NewText(data any) (string, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	object := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &object); err != nil {
+		indented, err := json.MarshalIndent(data, "", "  ")
+		return string(indented) + "\n", err
+	}
+
+	keys := slices.Sorted(maps.Keys(object))
+
+	keyWidth := 0
+	for _, key := range keys {
+		keyWidth = max(keyWidth, len(key)+1)
+	}
+
+	var b strings.Builder
+	for _, key := range keys {
+		value := string(object[key])
+		var s string
+		if err := json.Unmarshal(object[key], &s); err == nil {
+			value = s
+		}
+		fmt.Fprintf(&b, "%-*s %s\n", keyWidth, key+":", value)
+	}
+
+	return b.String(), nil
+}
+
+func cvssOf(finding *platformapi.GetAssessmentTaskFindings_2XX_Item) float32 {
+	if finding.Cvss == nil {
+		return -1
+	}
+
+	return *finding.Cvss
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width <= 0 || len(runes) <= width {
+		return s
+	}
+
+	return string(runes[:width-1]) + "…"
+}
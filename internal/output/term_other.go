@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package output
+
+import "os"
+
+// terminalWidth is unknown on platforms without TIOCGWINSZ, tables fall back to COLUMNS or are not truncated
+func terminalWidth(_ *os.File) int {
+	return 0
+}
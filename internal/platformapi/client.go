@@ -1,10 +1,14 @@
 package platformapi
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
-	"os"
+	"slices"
+	"strings"
+	"time"
 
 	types "github.com/oapi-codegen/runtime/types"
 	"github.com/rs/zerolog"
@@ -37,6 +41,9 @@ type Config struct {
 	Host      string
 	UserAgent string
 	Token     string
+	// MaxRetries of idempotent requests on connection errors, 429 and 5XX responses, 0 disables retries
+	MaxRetries   int
+	RetryMaxWait time.Duration
 }
 
 func ClientFromConfig(config Config, doer HttpRequestDoer) (*ClientWithResponses, error) {
@@ -44,6 +51,10 @@ func ClientFromConfig(config Config, doer HttpRequestDoer) (*ClientWithResponses
 		doer = &LoggingDoer{&http.Client{}}
 	}
 
+	if config.MaxRetries > 0 {
+		doer = &RetryDoer{Doer: doer, MaxRetries: config.MaxRetries, MaxWait: config.RetryMaxWait}
+	}
+
 	return NewClientWithResponses(config.Host,
 		WithRequestEditorFn(func(ctx context.Context, req *http.Request) error {
 			req.Header.Add("User-Agent", config.UserAgent)
@@ -57,20 +68,32 @@ type TriggerAssessmentParams struct {
 	Group        types.UUID
 	AnalysisType string
 	Platform     string
+	// Appstore assesses the latest app store version available to NowSecure instead of the latest uploaded binary
+	Appstore bool
+	// Failfast runs the automation pass of the dynamic analysis first, so scripting failures surface quickly
+	Failfast bool
+	// HideSensitiveValues hides the values of secrets in the app configuration returned with the assessment
+	HideSensitiveValues bool
 }
 
 func TriggerAssessment(ctx context.Context, client ClientWithResponsesInterface, p TriggerAssessmentParams) (*PostAppPlatformPackageAssessmentResponse, error) {
+	params := &PostAppPlatformPackageAssessmentParams{
+		Group:                   &p.Group,
+		AppstoreDownload:        nil,
+		Failfast:                Ptr(p.Failfast),
+		AnalysisType:            (*PostAppPlatformPackageAssessmentParamsAnalysisType)(&p.AnalysisType),
+		HideSensitiveDataValues: Ptr(p.HideSensitiveValues),
+	}
+
+	if p.Appstore {
+		params.AppstoreDownload = Ptr("*")
+	}
+
 	response, err := client.PostAppPlatformPackageAssessmentWithResponse(
 		ctx,
 		PostAppPlatformPackageAssessmentParamsPlatform(p.Platform),
 		p.PackageName,
-		&PostAppPlatformPackageAssessmentParams{
-			Group:                   &p.Group,
-			AppstoreDownload:        nil,
-			Failfast:                Ptr(true),
-			AnalysisType:            (*PostAppPlatformPackageAssessmentParamsAnalysisType)(&p.AnalysisType),
-			HideSensitiveDataValues: Ptr(false),
-		},
+		params,
 	)
 	if err != nil {
 		return nil, err
@@ -87,47 +110,6 @@ func TriggerAssessment(ctx context.Context, client ClientWithResponsesInterface,
 	return response, nil
 }
 
-type UploadFileParams struct {
-	AnalysisType string
-	Group        types.UUID
-	File         *os.File
-}
-
-func UploadFile(ctx context.Context, client ClientWithResponsesInterface, p UploadFileParams) (*PostBuild2XX1, error) {
-	params := &PostBuildParams{
-		AnalysisType:            (*PostBuildParamsAnalysisType)(&p.AnalysisType),
-		Group:                   &p.Group,
-		Assessment:              Ptr(true),
-		Version:                 nil,
-		HideSensitiveDataValues: Ptr(false),
-	}
-
-	if p.AnalysisType == "full" {
-		params.AnalysisType = nil
-	}
-
-	response, err := client.PostBuildWithBodyWithResponse(ctx, params,
-		"application/octet-stream", p.File)
-	if err != nil {
-		return nil, err
-	}
-
-	if response.HTTPResponse.StatusCode >= 400 && response.HTTPResponse.StatusCode < 500 {
-		return nil, response.JSON4XX
-	}
-
-	if response.HTTPResponse.StatusCode >= 500 {
-		return nil, response.JSON5XX
-	}
-
-	// NOTE: if the assessment build param gets changed to 'false' then handle PostBuild2XX0 as well
-	buildResponse := PostBuild2XX1{}
-
-	err = json.Unmarshal(response.Body, &buildResponse)
-
-	return &buildResponse, err
-}
-
 func GetAppList(ctx context.Context, client ClientWithResponsesInterface, p GetAppParams) ([]LabApp, error) {
 	response, err := client.GetAppWithResponse(ctx, &p)
 	if err != nil {
@@ -197,3 +179,264 @@ func GetFindings(ctx context.Context, client ClientWithResponsesInterface, task
 
 	return resp.JSON2XX, nil
 }
+
+type FindAssessmentParams struct {
+	Platform    string
+	PackageName string
+	Group       types.UUID
+	// Digest is the SHA-256 of the binary
+	Digest string
+	// AnalysisType is the requested analysis. A full analysis is only satisfied by an assessment with dynamic results
+	AnalysisType string
+}
+
+// ExistingAssessment identifies a completed assessment found by FindCompletedAssessment
+type ExistingAssessment struct {
+	Application types.UUID
+	Ref         types.UUID
+	Task        float64
+	Created     time.Time
+}
+
+// FindCompletedAssessment returns the most recent completed assessment of the binary with the given digest covering
+// the requested analysis type, or nil if the package has none, including when the package doesn't exist yet
+func FindCompletedAssessment(ctx context.Context, client ClientWithResponsesInterface, p FindAssessmentParams) (*ExistingAssessment, error) {
+	resp, err := client.GetAppPlatformPackageAssessmentWithResponse(
+		ctx,
+		GetAppPlatformPackageAssessmentParamsPlatform(p.Platform),
+		p.PackageName,
+		&GetAppPlatformPackageAssessmentParams{Group: Ptr(p.Group.String())},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.HTTPResponse.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if resp.HTTPResponse.StatusCode >= 400 && resp.HTTPResponse.StatusCode < 500 {
+		return nil, resp.JSON4XX
+	}
+
+	if resp.HTTPResponse.StatusCode >= 500 {
+		return nil, resp.JSON5XX
+	}
+
+	if resp.JSON2XX == nil {
+		return nil, nil
+	}
+
+	var found *ExistingAssessment
+	for _, assessment := range *resp.JSON2XX {
+		if assessment.Cancelled || assessment.Binary == nil || !strings.EqualFold(*assessment.Binary, p.Digest) {
+			continue
+		}
+
+		if assessment.TaskStatus == nil || *assessment.TaskStatus != "completed" || assessment.AdjustedScore == nil {
+			continue
+		}
+
+		// A static-only assessment completes without a dynamic analysis, which a full analysis has to gate on
+		if p.AnalysisType == "full" && assessment.Status.Dynamic != "completed" {
+			continue
+		}
+
+		var created time.Time
+		if assessment.Created != nil {
+			created = *assessment.Created
+		}
+		if found != nil && !created.After(found.Created) {
+			continue
+		}
+
+		found = &ExistingAssessment{
+			Ref:     assessment.Ref,
+			Task:    float64(assessment.Task),
+			Created: created,
+		}
+		if assessment.Application != nil {
+			found.Application = *assessment.Application
+		}
+	}
+
+	return found, nil
+}
+
+type CancelAssessmentParams struct {
+	Platform    string
+	PackageName string
+	TaskId      float64
+}
+
+// CancelAssessment stops a running assessment, returning the state the platform left it in
+func CancelAssessment(ctx context.Context, client ClientWithResponsesInterface, p CancelAssessmentParams) (string, error) {
+	resp, err := client.PostAppPlatformPackageAssessmentTaskCancelWithResponse(
+		ctx,
+		PostAppPlatformPackageAssessmentTaskCancelParamsPlatform(p.Platform),
+		p.PackageName,
+		float32(p.TaskId),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.HTTPResponse.StatusCode >= 400 && resp.HTTPResponse.StatusCode < 500 {
+		return "", resp.JSON4XX
+	}
+
+	if resp.HTTPResponse.StatusCode >= 500 {
+		return "", resp.JSON5XX
+	}
+
+	if resp.JSON2XX == nil {
+		return "", nil
+	}
+
+	return string(resp.JSON2XX.Status), nil
+}
+
+type ReportFormat string
+
+const (
+	ReportPDF  ReportFormat = "pdf"
+	ReportHTML ReportFormat = "html"
+	ReportJSON ReportFormat = "json"
+)
+
+// ParseReportFormats parses a comma separated list of report formats, e.g. pdf,html
+func ParseReportFormats(s string) ([]ReportFormat, error) {
+	var formats []ReportFormat
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+
+		format := ReportFormat(part)
+		switch format {
+		case ReportPDF, ReportHTML, ReportJSON:
+		default:
+			return nil, fmt.Errorf("unknown report format %q, must be one of: pdf, html, json", part)
+		}
+
+		if !slices.Contains(formats, format) {
+			formats = append(formats, format)
+		}
+	}
+
+	return formats, nil
+}
+
+// DownloadReport fetches the rendered report of a completed assessment
+func DownloadReport(ctx context.Context, client ClientWithResponsesInterface, ref types.UUID, format ReportFormat) ([]byte, error) {
+	var (
+		httpResponse *http.Response
+		body         []byte
+		json4XX      *LabRouteError
+		json5XX      *LabRouteError
+	)
+
+	switch format {
+	case ReportPDF:
+		resp, err := client.GetAssessmentRefReportPdfWithResponse(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		httpResponse, body, json4XX, json5XX = resp.HTTPResponse, resp.Body, resp.JSON4XX, resp.JSON5XX
+	case ReportHTML:
+		resp, err := client.GetAssessmentRefReportHtmlWithResponse(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		httpResponse, body, json4XX, json5XX = resp.HTTPResponse, resp.Body, resp.JSON4XX, resp.JSON5XX
+	case ReportJSON:
+		resp, err := client.GetAssessmentRefReportJsonWithResponse(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		httpResponse, body, json4XX, json5XX = resp.HTTPResponse, resp.Body, resp.JSON4XX, resp.JSON5XX
+	default:
+		return nil, fmt.Errorf("unknown report format %q", format)
+	}
+
+	if httpResponse.StatusCode >= 400 && httpResponse.StatusCode < 500 && json4XX != nil {
+		return nil, json4XX
+	}
+
+	if httpResponse.StatusCode >= 500 && json5XX != nil {
+		return nil, json5XX
+	}
+
+	// Report endpoints don't necessarily answer errors with JSON
+	if httpResponse.StatusCode >= 400 {
+		return nil, fmt.Errorf("failed to download %s report: %s", format, httpResponse.Status)
+	}
+
+	return body, nil
+}
+
+type AppConfigParams struct {
+	Platform    string
+	PackageName string
+	Group       types.UUID
+}
+
+// GetAppConfig fetches the dynamic, static and integrations configuration of an application as generic JSON
+// values, which keeps settings the client doesn't model intact when they are written back
+func GetAppConfig(ctx context.Context, client ClientWithResponsesInterface, p AppConfigParams) (map[string]any, error) {
+	resp, err := client.GetAppPlatformPackageConfigWithResponse(
+		ctx,
+		GetAppPlatformPackageConfigParamsPlatform(p.Platform),
+		p.PackageName,
+		&GetAppPlatformPackageConfigParams{Group: &p.Group},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.HTTPResponse.StatusCode >= 400 && resp.HTTPResponse.StatusCode < 500 {
+		return nil, resp.JSON4XX
+	}
+
+	if resp.HTTPResponse.StatusCode >= 500 {
+		return nil, resp.JSON5XX
+	}
+
+	config := map[string]any{}
+	if err := json.Unmarshal(resp.Body, &config); err != nil {
+		return nil, fmt.Errorf("invalid app configuration response: %w", err)
+	}
+
+	return config, nil
+}
+
+// SetAppConfig updates the configuration of an application with the sections present in config
+func SetAppConfig(ctx context.Context, client ClientWithResponsesInterface, p AppConfigParams, config map[string]any) error {
+	body, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.PostAppPlatformPackageConfigWithBodyWithResponse(
+		ctx,
+		PostAppPlatformPackageConfigParamsPlatform(p.Platform),
+		p.PackageName,
+		&PostAppPlatformPackageConfigParams{Group: &p.Group},
+		"application/json",
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+
+	if resp.HTTPResponse.StatusCode >= 400 && resp.HTTPResponse.StatusCode < 500 {
+		return resp.JSON4XX
+	}
+
+	if resp.HTTPResponse.StatusCode >= 500 {
+		return resp.JSON5XX
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package platformapi
+
+import (
+	"io"
+	"math"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// progressReader logs how much of an upload body has been read, so that long uploads keep producing output
+// and aren't mistaken for a hung job
+type progressReader struct {
+	r        io.Reader
+	log      *zerolog.Logger
+	size     int64
+	read     int64
+	interval time.Duration
+	start    time.Time
+	last     time.Time
+}
+
+func newProgressReader(r io.Reader, log *zerolog.Logger, size int64, interval time.Duration) *progressReader {
+	now := time.Now()
+	return &progressReader{
+		r:        r,
+		log:      log,
+		size:     size,
+		interval: interval,
+		start:    now,
+		last:     now,
+	}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+
+	if p.interval > 0 && time.Since(p.last) >= p.interval {
+		p.last = time.Now()
+		p.logProgress()
+	}
+
+	return n, err
+}
+
+func (p *progressReader) logProgress() {
+	elapsed := time.Since(p.start)
+	rate := bytesPerSecond(p.read, elapsed)
+
+	event := p.log.Info().
+		Int64("Bytes", p.read).
+		Int64("Size", p.size).
+		Int64("BytesPerSecond", rate)
+	if p.size > 0 {
+		event = event.Float64("Percent", math.Round(float64(p.read)*1000/float64(p.size))/10)
+	}
+	if rate > 0 && p.size > p.read {
+		event = event.Dur("ETA", time.Duration(float64(p.size-p.read)/float64(rate)*float64(time.Second)).Round(time.Second))
+	}
+	event.Msg("Upload progress")
+}
+
+// complete logs the outcome of a finished upload
+func (p *progressReader) complete() {
+	elapsed := time.Since(p.start)
+	p.log.Info().
+		Int64("Size", p.size).
+		Dur("Duration", elapsed.Round(time.Millisecond)).
+		Int64("BytesPerSecond", bytesPerSecond(p.size, elapsed)).
+		Msg("Upload complete")
+}
+
+func bytesPerSecond(n int64, elapsed time.Duration) int64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return int64(float64(n) / elapsed.Seconds())
+}
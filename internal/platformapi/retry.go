@@ -0,0 +1,112 @@
+package platformapi
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const retryBaseWait = 500 * time.Millisecond
+
+// RetryDoer retries idempotent requests which fail to connect or are answered with a 429 or 5XX,
+// waiting with jittered exponential backoff or for as long as the Retry-After header asks
+type RetryDoer struct {
+	Doer       HttpRequestDoer
+	MaxRetries int
+	MaxWait    time.Duration
+}
+
+func (rd *RetryDoer) Do(req *http.Request) (*http.Response, error) {
+	log := zerolog.Ctx(req.Context())
+	for attempt := 0; ; attempt++ {
+		resp, err := rd.Doer.Do(req)
+		if attempt >= rd.MaxRetries || !isIdempotent(req) || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		wait := retryWait(attempt, rd.MaxWait, resp)
+		event := log.Warn().Str("Path", req.URL.Path).Int("Attempt", attempt+1).Dur("Wait", wait)
+		if err != nil {
+			event = event.Err(err)
+		} else {
+			event = event.Str("Status", resp.Status)
+			resp.Body.Close()
+		}
+		event.Msg("Retrying platform request")
+
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		if req.Body != nil && req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// retryWait is how long to wait before retrying a failed attempt, at most maxWait
+func retryWait(attempt int, maxWait time.Duration, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(wait, maxWait)
+		}
+	}
+
+	wait := min(retryBaseWait<<attempt, maxWait)
+	// Jitter between half and the full wait so that concurrent clients don't retry in lockstep
+	return wait/2 + rand.N(wait/2+1)
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	}
+
+	return false
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		// A cancelled request is not a transient failure
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// retryAfter parses a Retry-After header given in either seconds or as an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -22,6 +22,26 @@ func (s Status) String() string {
 	return "unknown"
 }
 
+// AssessmentStatus maps an assessment response onto a Status. A completed assessment is only
+// considered complete once its score has been calculated, and any non-terminal state is pending
+func AssessmentStatus(resp *GetAppPlatformPackageAssessmentTaskResponse) Status {
+	if resp == nil || resp.StatusCode() != 200 || resp.JSON2XX == nil || resp.JSON2XX.TaskStatus == nil {
+		return Pending
+	}
+
+	switch string(*resp.JSON2XX.TaskStatus) {
+	case Completed.String():
+		if resp.JSON2XX.AdjustedScore == nil {
+			return Pending
+		}
+		return Completed
+	case Failed.String():
+		return Failed
+	}
+
+	return Pending
+}
+
 // TODO move to some sort of utility file in the future
 func Ptr[T any](v T) *T {
 	return &v
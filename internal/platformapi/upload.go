@@ -0,0 +1,176 @@
+package platformapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	types "github.com/oapi-codegen/runtime/types"
+	"github.com/rs/zerolog"
+)
+
+type UploadFileParams struct {
+	AnalysisType string
+	Group        types.UUID
+	// Version overrides the version the platform reads from the binary's metadata when set
+	Version string
+	// HideSensitiveValues hides the values of secrets in the app configuration returned with the assessment
+	HideSensitiveValues bool
+	// Digest is the SHA-256 of File, computed when empty
+	Digest string
+	// File is rewound before every attempt, so it must be seekable rather than a stream
+	File io.ReadSeeker
+	// Attempts is the number of times to try the upload, at least one
+	Attempts int
+	// AttemptTimeout bounds each attempt separately from any deadline on ctx, 0 for no limit
+	AttemptTimeout time.Duration
+	RetryMaxWait   time.Duration
+	// ProgressInterval is how often upload progress is logged, 0 to only log the completed upload
+	ProgressInterval time.Duration
+}
+
+// UploadFile uploads a binary for assessment. Failed attempts (connection errors, attempt timeouts, 429 and 5XX
+// responses, and corrupted uploads) are restarted from the beginning of the file, and the digest reported by
+// the platform is verified against the SHA-256 of the file. The assessment of a corrupted upload is cancelled
+// before the next attempt
+func UploadFile(ctx context.Context, client ClientWithResponsesInterface, p UploadFileParams) (*PostBuild2XX1, error) {
+	log := zerolog.Ctx(ctx)
+	params := &PostBuildParams{
+		AnalysisType:            (*PostBuildParamsAnalysisType)(&p.AnalysisType),
+		Group:                   &p.Group,
+		Assessment:              Ptr(true),
+		Version:                 nil,
+		HideSensitiveDataValues: Ptr(p.HideSensitiveValues),
+	}
+
+	if p.AnalysisType == "full" {
+		params.AnalysisType = nil
+	}
+
+	if p.Version != "" {
+		params.Version = &p.Version
+	}
+
+	digest := p.Digest
+	if digest == "" {
+		var err error
+		if digest, err = FileDigest(p.File); err != nil {
+			return nil, err
+		}
+		log.Debug().Str("SHA256", digest).Msg("Computed binary digest")
+	}
+
+	attempts := max(p.Attempts, 1)
+	for attempt := 0; ; attempt++ {
+		buildResponse, retry, err := uploadAttempt(ctx, client, params, p, digest)
+		if err == nil {
+			return buildResponse, nil
+		}
+
+		if !retry || attempt+1 >= attempts || ctx.Err() != nil {
+			return nil, err
+		}
+
+		wait := retryWait(attempt, p.RetryMaxWait, nil)
+		log.Warn().Err(err).Int("Attempt", attempt+1).Dur("Wait", wait).Msg("Retrying upload")
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// uploadAttempt makes a single upload, returning whether a failure is worth retrying
+func uploadAttempt(ctx context.Context, client ClientWithResponsesInterface, params *PostBuildParams, p UploadFileParams, digest string) (*PostBuild2XX1, bool, error) {
+	size, err := p.File.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if _, err := p.File.Seek(0, io.SeekStart); err != nil {
+		return nil, false, err
+	}
+
+	attemptCtx := ctx
+	if p.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, p.AttemptTimeout)
+		defer cancel()
+	}
+
+	body := newProgressReader(p.File, zerolog.Ctx(ctx), size, p.ProgressInterval)
+	response, err := client.PostBuildWithBodyWithResponse(attemptCtx, params,
+		"application/octet-stream", body)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			return nil, true, fmt.Errorf("upload attempt timed out after %s: %w", p.AttemptTimeout, err)
+		}
+		// Connection errors are transient, a cancelled or expired parent context is not
+		return nil, ctx.Err() == nil, err
+	}
+
+	statusCode := response.HTTPResponse.StatusCode
+	if statusCode >= 400 && statusCode < 500 {
+		retry := statusCode == http.StatusTooManyRequests
+		if response.JSON4XX != nil {
+			return nil, retry, response.JSON4XX
+		}
+		return nil, retry, fmt.Errorf("upload failed: %s", response.HTTPResponse.Status)
+	}
+
+	if statusCode >= 500 {
+		if response.JSON5XX != nil {
+			return nil, true, response.JSON5XX
+		}
+		return nil, true, fmt.Errorf("upload failed: %s", response.HTTPResponse.Status)
+	}
+
+	body.complete()
+
+	// NOTE: if the assessment build param gets changed to 'false' then handle PostBuild2XX0 as well
+	buildResponse := PostBuild2XX1{}
+	if err := json.Unmarshal(response.Body, &buildResponse); err != nil {
+		return nil, false, err
+	}
+
+	if buildResponse.Binary == nil {
+		zerolog.Ctx(ctx).Debug().Msg("Platform did not report a binary digest, skipping verification")
+		return &buildResponse, false, nil
+	}
+
+	if !strings.EqualFold(*buildResponse.Binary, digest) {
+		err := fmt.Errorf("uploaded binary digest %s does not match the local SHA-256 %s", *buildResponse.Binary, digest)
+		// The platform already started an assessment of the corrupted upload, stop it before uploading again
+		if _, cancelErr := CancelAssessment(ctx, client, CancelAssessmentParams{
+			Platform:    buildResponse.Platform,
+			PackageName: buildResponse.Package,
+			TaskId:      buildResponse.Task,
+		}); cancelErr != nil {
+			return nil, false, errors.Join(err, fmt.Errorf("cancelling assessment %.0f: %w", buildResponse.Task, cancelErr))
+		}
+		zerolog.Ctx(ctx).Warn().Float64("Task", buildResponse.Task).Msg("Cancelled assessment of a corrupted upload")
+		return nil, true, err
+	}
+
+	return &buildResponse, false, nil
+}
+
+// FileDigest returns the hex encoded SHA-256 of a file, the form the platform identifies binaries by
+func FileDigest(file io.ReadSeeker) (string, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
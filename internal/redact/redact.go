@@ -0,0 +1,223 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Mask replaces every redacted value
+const Mask = "[REDACTED]"
+
+// sensitiveWords are matched against field names lowercased and stripped of separators, so "api_key" and "apiKey"
+// both match "apikey"
+var sensitiveWords = []string{
+	"password",
+	"passwd",
+	"passphrase",
+	"secret",
+	"token",
+	"apikey",
+	"accesskey",
+	"privatekey",
+	"credential",
+	"authorization",
+	"cookie",
+}
+
+// minSecretLength keeps secrets too short to be real credentials, such as a placeholder token in a test setup,
+// from masking ordinary text
+const minSecretLength = 6
+
+var nameSeparators = strings.NewReplacer("_", "", "-", "", ".", "", " ", "")
+
+// Redactor masks secrets in log events and command output: known secrets such as the API token, matches of
+// user-supplied patterns, and, when enabled, the values of fields with sensitive names. A nil Redactor masks nothing
+type Redactor struct {
+	keys     bool
+	secrets  []string
+	patterns []*regexp.Regexp
+}
+
+// New returns a Redactor masking the secrets and the matches of patterns, and the values of sensitive
+// fields when keys is set
+func New(keys bool, secrets []string, patterns []string) (*Redactor, error) {
+	r := &Redactor{keys: keys}
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+// IsSensitive reports whether a field name suggests its value is a secret
+func IsSensitive(name string) bool {
+	normalized := nameSeparators.Replace(strings.ToLower(name))
+	for _, word := range sensitiveWords {
+		if strings.Contains(normalized, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String masks the secrets and pattern matches in s
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+
+	for _, re := range r.patterns {
+		s = re.ReplaceAllLiteralString(s, Mask)
+	}
+
+	return s
+}
+
+// Value returns data with its secrets masked, as generic JSON values, or data itself when there is nothing to mask
+func (r *Redactor) Value(data any) (any, error) {
+	if r == nil {
+		return data, nil
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := decode(body)
+	if err != nil {
+		return nil, err
+	}
+
+	value, changed := r.redact(value)
+	if !changed {
+		// Keep the original field order when there is nothing to hide
+		return data, nil
+	}
+
+	return value, nil
+}
+
+// JSON masks the secrets in a JSON document, such as a log event, falling back to masking the raw text when it
+// can't be decoded
+func (r *Redactor) JSON(p []byte) []byte {
+	if r == nil {
+		return p
+	}
+
+	value, err := decode(p)
+	if err != nil {
+		return []byte(r.String(string(p)))
+	}
+
+	value, changed := r.redact(value)
+	if !changed {
+		return p
+	}
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		return []byte(r.String(string(p)))
+	}
+
+	if bytes.HasSuffix(p, []byte("\n")) {
+		body = append(body, '\n')
+	}
+
+	return body
+}
+
+// decode keeps numbers as written, e.g. so large task IDs don't lose precision
+func decode(body []byte) (any, error) {
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	var value any
+	if err := d.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// redact masks the secrets in a decoded JSON value, in place for objects and arrays, reporting whether it masked any
+func (r *Redactor) redact(v any) (any, bool) {
+	switch t := v.(type) {
+	case string:
+		masked := r.String(t)
+		return masked, masked != t
+	case map[string]any:
+		changed := false
+		if r.keys {
+			// Configuration is commonly a list of {"key": "API_TOKEN", "value": "..."} entries
+			for _, nameField := range []string{"key", "name"} {
+				if name, ok := t[nameField].(string); ok && IsSensitive(name) {
+					if value, ok := t["value"]; ok && isLeaf(value) && value != Mask {
+						t["value"] = Mask
+						changed = true
+					}
+				}
+			}
+		}
+
+		for field, child := range t {
+			// Objects and arrays under a sensitive name are masked field by field instead, so that e.g. a
+			// "token_settings" object keeps its structure
+			if r.keys && IsSensitive(field) && isLeaf(child) {
+				if child != Mask {
+					t[field] = Mask
+					changed = true
+				}
+				continue
+			}
+
+			masked, childChanged := r.redact(child)
+			if childChanged {
+				t[field] = masked
+				changed = true
+			}
+		}
+		return t, changed
+	case []any:
+		changed := false
+		for i, child := range t {
+			masked, childChanged := r.redact(child)
+			if childChanged {
+				t[i] = masked
+				changed = true
+			}
+		}
+		return t, changed
+	}
+
+	return v, false
+}
+
+// isLeaf reports whether a value is a non-empty scalar
+func isLeaf(v any) bool {
+	switch t := v.(type) {
+	case nil, map[string]any, []any:
+		return false
+	case string:
+		return t != ""
+	}
+
+	return true
+}
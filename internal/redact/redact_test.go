@@ -0,0 +1,174 @@
+package redact
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSensitive(t *testing.T) {
+	tests := map[string]bool{
+		"password":         true,
+		"API_KEY":          true,
+		"apiKey":           true,
+		"client-secret":    true,
+		"Authorization":    true,
+		"private.key":      true,
+		"token_settings":   true,
+		"username":         false,
+		"region":           false,
+		"keyboard_enabled": false,
+	}
+
+	for name, sensitive := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, sensitive, IsSensitive(name))
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Run("Invalid pattern", func(t *testing.T) {
+		_, err := New(false, nil, []string{"(unclosed"})
+		require.ErrorContains(t, err, `invalid redact pattern "(unclosed"`)
+	})
+
+	t.Run("Short secrets are ignored", func(t *testing.T) {
+		r, err := New(false, []string{"", "abc", "secret-token"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "abc [REDACTED]", r.String("abc secret-token"))
+	})
+}
+
+func TestString(t *testing.T) {
+	r, err := New(false, []string{"secret-token"}, []string{`build-key-[0-9]+`})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name, input, expected string
+	}{
+		{name: "Secret", input: "Bearer secret-token", expected: "Bearer [REDACTED]"},
+		{name: "Pattern", input: "key=build-key-42&page=1", expected: "key=[REDACTED]&page=1"},
+		{name: "Every occurrence", input: "secret-token secret-token build-key-1", expected: "[REDACTED] [REDACTED] [REDACTED]"},
+		{name: "Nothing to mask", input: "build-key-x", expected: "build-key-x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, r.String(tt.input))
+		})
+	}
+
+	t.Run("Nil redactor masks nothing", func(t *testing.T) {
+		var nilRedactor *Redactor
+		assert.Equal(t, "Bearer secret-token", nilRedactor.String("Bearer secret-token"))
+		assert.Equal(t, []byte(`{"token":"x"}`), nilRedactor.JSON([]byte(`{"token":"x"}`)))
+		value, err := nilRedactor.Value(map[string]any{"token": "x"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"token": "x"}, value)
+	})
+}
+
+func TestValue(t *testing.T) {
+	type settings struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+
+	tests := []struct {
+		name     string
+		keys     bool
+		input    any
+		expected any
+	}{
+		{
+			name:     "Sensitive fields",
+			keys:     true,
+			input:    settings{Username: "ci-user", Password: "hunter2"},
+			expected: map[string]any{"username": "ci-user", "password": Mask},
+		},
+		{
+			name:     "Sensitive fields are kept without keys",
+			input:    settings{Username: "ci-user", Password: "hunter2"},
+			expected: settings{Username: "ci-user", Password: "hunter2"},
+		},
+		{
+			name: "Key value entries",
+			keys: true,
+			input: []map[string]any{
+				{"key": "API_TOKEN", "value": "abc123"},
+				{"name": "REGION", "value": "us-east-1"},
+			},
+			expected: []any{
+				map[string]any{"key": "API_TOKEN", "value": Mask},
+				map[string]any{"name": "REGION", "value": "us-east-1"},
+			},
+		},
+		{
+			name:     "Objects under sensitive names keep their structure",
+			keys:     true,
+			input:    map[string]any{"token_settings": map[string]any{"ttl": 60, "secret": "s3"}},
+			expected: map[string]any{"token_settings": map[string]any{"ttl": json.Number("60"), "secret": Mask}},
+		},
+		{
+			name:     "Empty values are kept",
+			keys:     true,
+			input:    map[string]any{"password": ""},
+			expected: map[string]any{"password": ""},
+		},
+		{
+			name:     "Secrets in any field",
+			input:    map[string]any{"url": "https://host?t=secret-token"},
+			expected: map[string]any{"url": "https://host?t=[REDACTED]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := New(tt.keys, []string{"secret-token"}, nil)
+			require.NoError(t, err)
+
+			value, err := r.Value(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	r, err := New(true, []string{"secret-token"}, []string{`build-key-[0-9]+`})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name, input, expected string
+	}{
+		{
+			name:     "Log event",
+			input:    `{"level":"debug","Query":"key=build-key-42","password":"hunter2","message":"Platform request"}` + "\n",
+			expected: `{"Query":"key=[REDACTED]","level":"debug","message":"Platform request","password":"[REDACTED]"}` + "\n",
+		},
+		{
+			name:     "Unchanged events are kept as written",
+			input:    `{"level":"info","task":12345678901234567890}`,
+			expected: `{"level":"info","task":12345678901234567890}`,
+		},
+		{
+			name:     "Large numbers keep their precision",
+			input:    `{"task":12345678901234567890,"token":"abc"}`,
+			expected: `{"task":12345678901234567890,"token":"[REDACTED]"}`,
+		},
+		{
+			name:     "Malformed JSON is masked as text",
+			input:    `{"token": "secret-token"`,
+			expected: `{"token": "[REDACTED]"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, string(r.JSON([]byte(tt.input))))
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nowsecure/nowsecure-ci/internal/output"
+)
+
+const (
+	DefaultFile   = ".ns-ci-state.json"
+	formatVersion = 1
+)
+
+// State records a triggered assessment so that a later CI stage can resume polling for it
+type State struct {
+	Version  int       `json:"version"`
+	Platform string    `json:"platform"`
+	Package  string    `json:"package"`
+	Task     float64   `json:"task"`
+	Group    uuid.UUID `json:"group"`
+	URL      string    `json:"url"`
+	Created  time.Time `json:"created"`
+}
+
+func New(platform, packageName string, task float64, group uuid.UUID, url string) *State {
+	return &State{
+		Version:  formatVersion,
+		Platform: platform,
+		Package:  packageName,
+		Task:     task,
+		Group:    group,
+		URL:      url,
+		Created:  time.Now().UTC(),
+	}
+}
+
+func Load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &State{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+
+	if s.Version != formatVersion {
+		return nil, fmt.Errorf("unsupported state file version %d in %s", s.Version, path)
+	}
+
+	if s.Platform == "" || s.Package == "" || s.Task == 0 {
+		return nil, fmt.Errorf("state file %s is missing the platform, package or task", path)
+	}
+
+	return s, nil
+}
+
+func (s *State) Save(path string) error {
+	w, err := output.New(path, output.Pretty, output.Options{})
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	return w.WriteRaw(s)
+}
@@ -0,0 +1,148 @@
+package suppression
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	nserrors "github.com/nowsecure/nowsecure-ci/internal/errors"
+	"github.com/nowsecure/nowsecure-ci/internal/gate"
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+const (
+	DefaultFile = ".ns-ci-ignore.yaml"
+	dateLayout  = "2006-01-02"
+)
+
+// File is the version-controlled list of waived checks, e.g.
+//
+//	suppressions:
+//	  - check_id: allow_backup
+//	    platform: android
+//	    package: com.example.app
+//	    justification: Backups are encrypted by the app, see SEC-123
+//	    expires: 2025-12-31
+type File struct {
+	Suppressions []Suppression `yaml:"suppressions"`
+}
+
+type Suppression struct {
+	CheckID       string `yaml:"check_id" json:"check_id"`
+	Platform      string `yaml:"platform,omitempty" json:"platform,omitempty"`
+	Package       string `yaml:"package,omitempty" json:"package,omitempty"`
+	Justification string `yaml:"justification" json:"justification"`
+	Expires       string `yaml:"expires" json:"expires"`
+
+	expires time.Time
+}
+
+// Result is the outcome of applying a suppression file to an assessment
+type Result struct {
+	// Active maps check IDs to the unexpired suppression waiving them
+	Active  map[string]*Suppression
+	Expired []*Suppression
+}
+
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{}
+	if err := yaml.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("invalid suppression file %s: %w", path, err)
+	}
+
+	if err := f.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid suppression file %s: %w", path, err)
+	}
+
+	return f, nil
+}
+
+// Validate requires every suppression to name a check, justify it, and expire
+func (f *File) Validate() error {
+	var errs []error
+	for i := range f.Suppressions {
+		s := &f.Suppressions[i]
+		if s.CheckID == "" {
+			errs = append(errs, fmt.Errorf("suppression %d: check_id is required", i+1))
+		}
+
+		if strings.TrimSpace(s.Justification) == "" {
+			errs = append(errs, fmt.Errorf("suppression %d (%s): justification is required", i+1, s.CheckID))
+		}
+
+		expires, err := time.Parse(dateLayout, s.Expires)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("suppression %d (%s): expires must be a YYYY-MM-DD date", i+1, s.CheckID))
+		}
+		s.expires = expires
+	}
+
+	return errors.Join(errs...)
+}
+
+// Apply returns the suppressions in scope for the application, split into active and expired.
+// A suppression is valid through the end of its expiry date
+func (f *File) Apply(packageName, platform string, now time.Time) *Result {
+	result := &Result{Active: map[string]*Suppression{}}
+	for i := range f.Suppressions {
+		s := &f.Suppressions[i]
+		if s.Platform != "" && !strings.EqualFold(s.Platform, platform) {
+			continue
+		}
+
+		if s.Package != "" && s.Package != packageName {
+			continue
+		}
+
+		if now.After(s.expires.AddDate(0, 0, 1)) {
+			result.Expired = append(result.Expired, s)
+			continue
+		}
+
+		result.Active[s.CheckID] = s
+	}
+
+	return result
+}
+
+// Unsuppressed drops the affected findings waived by an active suppression
+func (r *Result) Unsuppressed(findings []platformapi.GetAssessmentTaskFindings_2XX_Item) []platformapi.GetAssessmentTaskFindings_2XX_Item {
+	var filtered []platformapi.GetAssessmentTaskFindings_2XX_Item
+	for i := range findings {
+		if _, ok := r.Active[findings[i].CheckId]; ok && findings[i].Affected {
+			continue
+		}
+		filtered = append(filtered, findings[i])
+	}
+
+	return filtered
+}
+
+var _ nserrors.CIError = (*ExpiredError)(nil)
+
+type ExpiredError struct {
+	Expired []*Suppression
+}
+
+func (e *ExpiredError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d suppression(s) have expired:", len(e.Expired))
+	for _, s := range e.Expired {
+		fmt.Fprintf(&b, "\n  - %s expired on %s (%s)", s.CheckID, s.Expires, s.Justification)
+	}
+
+	return b.String()
+}
+
+func (e *ExpiredError) ExitCode() int {
+	return gate.ExitCode
+}
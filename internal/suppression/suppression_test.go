@@ -0,0 +1,149 @@
+package suppression
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nowsecure/nowsecure-ci/internal/platformapi"
+)
+
+func writeFile(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Valid file", func(t *testing.T) {
+		f, err := Load(writeFile(t, `
+suppressions:
+  - check_id: allow_backup
+    platform: android
+    justification: Backups are encrypted by the app
+    expires: 2025-12-31
+`))
+		require.NoError(t, err)
+		require.Len(t, f.Suppressions, 1)
+		assert.Equal(t, "allow_backup", f.Suppressions[0].CheckID)
+		assert.Equal(t, time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC), f.Suppressions[0].expires)
+	})
+
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{name: "Malformed YAML", data: "suppressions: [", err: "invalid suppression file"},
+		{name: "Missing check ID", data: "suppressions: [{justification: x, expires: 2025-01-01}]", err: "suppression 1: check_id is required"},
+		{name: "Missing justification", data: "suppressions: [{check_id: a, justification: ' ', expires: 2025-01-01}]", err: "suppression 1 (a): justification is required"},
+		{name: "Missing expiry", data: "suppressions: [{check_id: a, justification: x}]", err: "suppression 1 (a): expires must be a YYYY-MM-DD date"},
+		{name: "Invalid expiry", data: "suppressions: [{check_id: a, justification: x, expires: 31/12/2025}]", err: "expires must be a YYYY-MM-DD date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeFile(t, tt.data))
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestApply(t *testing.T) {
+	f := &File{Suppressions: []Suppression{
+		{CheckID: "allow_backup", Platform: "android", Justification: "x", Expires: "2025-06-30"},
+		{CheckID: "weak_crypto", Package: "com.example.app", Justification: "x", Expires: "2025-06-30"},
+		{CheckID: "cleartext", Justification: "x", Expires: "2025-01-31"},
+		{CheckID: "ats_disabled", Platform: "ios", Justification: "x", Expires: "2025-12-31"},
+	}}
+	require.NoError(t, f.Validate())
+
+	tests := []struct {
+		name        string
+		packageName string
+		platform    string
+		now         time.Time
+		active      []string
+		expired     []string
+	}{
+		{
+			name:        "Scoped by platform and package",
+			packageName: "com.example.app",
+			platform:    "Android",
+			now:         time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC),
+			active:      []string{"allow_backup", "weak_crypto", "cleartext"},
+		},
+		{
+			name:        "Other package",
+			packageName: "com.example.other",
+			platform:    "ios",
+			now:         time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC),
+			active:      []string{"cleartext", "ats_disabled"},
+		},
+		{
+			name:        "Valid through the end of the expiry date",
+			packageName: "com.example.other",
+			platform:    "android",
+			now:         time.Date(2025, 1, 31, 23, 59, 0, 0, time.UTC),
+			active:      []string{"allow_backup", "cleartext"},
+		},
+		{
+			name:        "Expired the day after",
+			packageName: "com.example.app",
+			platform:    "android",
+			now:         time.Date(2025, 2, 1, 0, 1, 0, 0, time.UTC),
+			active:      []string{"allow_backup", "weak_crypto"},
+			expired:     []string{"cleartext"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := f.Apply(tt.packageName, tt.platform, tt.now)
+
+			assert.Len(t, result.Active, len(tt.active))
+			for _, checkID := range tt.active {
+				assert.Contains(t, result.Active, checkID)
+			}
+
+			var expired []string
+			for _, s := range result.Expired {
+				expired = append(expired, s.CheckID)
+			}
+			assert.Equal(t, tt.expired, expired)
+		})
+	}
+
+	t.Run("Expired error lists the suppressions", func(t *testing.T) {
+		result := f.Apply("com.example.app", "android", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+		err := &ExpiredError{Expired: result.Expired}
+		assert.Equal(t, "3 suppression(s) have expired:\n"+
+			"  - allow_backup expired on 2025-06-30 (x)\n"+
+			"  - weak_crypto expired on 2025-06-30 (x)\n"+
+			"  - cleartext expired on 2025-01-31 (x)", err.Error())
+	})
+}
+
+func TestUnsuppressed(t *testing.T) {
+	result := &Result{Active: map[string]*Suppression{"allow_backup": {CheckID: "allow_backup"}}}
+	findings := []platformapi.GetAssessmentTaskFindings_2XX_Item{
+		{CheckId: "allow_backup", Affected: true},
+		{CheckId: "allow_backup", Affected: false},
+		{CheckId: "weak_crypto", Affected: true},
+	}
+
+	assert.Equal(t, []platformapi.GetAssessmentTaskFindings_2XX_Item{
+		{CheckId: "allow_backup", Affected: false},
+		{CheckId: "weak_crypto", Affected: true},
+	}, result.Unsuppressed(findings))
+}
@@ -3,13 +3,14 @@ package main
 import (
 	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/rs/zerolog"
 	"github.com/spf13/viper"
 
 	cmd "github.com/nowsecure/nowsecure-ci/cmd/ns"
 	"github.com/nowsecure/nowsecure-ci/internal"
-	nserrors "github.com/nowsecure/nowsecure-ci/internal/errors"
 )
 
 func main() {
@@ -20,16 +21,16 @@ func main() {
 		Level(zerolog.WarnLevel).
 		WithContext(context.Background())
 
+	// CI runners send SIGINT or SIGTERM when a job is cancelled, stop polling and clean up rather than dying outright
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	v := viper.New()
 	config := internal.BaseConfig{}
 
 	root := cmd.RootCommand(ctx, v, &config)
 
 	if err := root.ExecuteContext(ctx); err != nil {
-		if reqErr, ok := err.(nserrors.CIError); ok {
-			zerolog.Ctx(ctx).Error().Any("LabRouteError", reqErr).Msg("API Error Response")
-			os.Exit(reqErr.ExitCode())
-		}
-		zerolog.Ctx(ctx).Fatal().Msg(err.Error())
+		os.Exit(cmd.LogError(ctx, &config, err))
 	}
 }